@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,87 +13,208 @@ import (
 
 	"github.com/ArtemChadaev/RedGo/internal/config"
 	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/grpcapi"
 	"github.com/ArtemChadaev/RedGo/internal/handler"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/metrics"
 	"github.com/ArtemChadaev/RedGo/internal/repository"
 	"github.com/ArtemChadaev/RedGo/internal/service"
+	"github.com/ArtemChadaev/RedGo/internal/tracing"
 	"github.com/ArtemChadaev/RedGo/internal/worker"
+	"github.com/ArtemChadaev/RedGo/migrate"
 )
 
 func main() {
+	// server migrate up|down|status|verify|baseline — миграции без запуска сервиса
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if errors.Is(err, errMigrateUsage) {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		if err != nil {
+			fatal("migrate failed", err)
+		}
+		return
+	}
+
 	// 0. Конфиг и базовый контекст для сигналов ОС
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("failed to config: %s", err)
+		fatal("failed to load config", err)
+	}
+	if err := logging.Setup(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fatal("failed to configure logging", err)
 	}
+	// Секреты в выводе замаскированы, см. Config.LogValue
+	slog.Info("effective config", "config", cfg)
 
 	// Создаем контекст, который отменится при Ctrl+C или docker stop
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// 1. Инициализация ресурсов (БД и Redis)
-	db, err := repository.NewPostgresDB(repository.PostgresConfig{
-		Host:     cfg.DBHost,
-		Port:     cfg.DBPort,
-		Username: cfg.DBUser,
-		Database: cfg.DBName,
-		Password: cfg.DBPassword,
-		SSLMode:  "disable",
+	// Трейсинг поднимаем до ресурсов: драйверы Postgres и Redis берут провайдер при создании
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Endpoint:    cfg.OTLPEndpoint,
+		ServiceName: cfg.OTELServiceName,
+		SampleRatio: cfg.TraceSampleRatio,
 	})
 	if err != nil {
-		log.Fatalf("failed to initialize db: %s", err.Error())
+		fatal("failed to initialize tracing", err)
+	}
+
+	// 1. Инициализация ресурсов (БД и Redis)
+	db, err := repository.NewPostgresDB(postgresConfig(cfg))
+	if err != nil {
+		fatal("failed to initialize db", err)
+	}
+
+	// Схему обновляет первая стартовавшая реплика, остальные ждут ее на advisory lock
+	if cfg.MigrateOnStart {
+		migrator, err := repository.NewMigrator(db, migrate.Files)
+		if err != nil {
+			fatal("failed to load migrations", err)
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			fatal("failed to apply migrations", err)
+		}
 	}
 
 	redisClient, err := repository.NewRedisClient(repository.RedisConfig{
 		Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
 		Password: cfg.RedisPassword,
 		DB:       0,
+		PoolSize: cfg.RedisPoolSize,
 	})
 	if err != nil {
-		log.Fatalf("failed to initialize redis: %s", err.Error())
+		fatal("failed to initialize redis", err)
 	}
 
-	// 2. Инициализация Воркера
+	// 2. Инициализация слоев (Repository -> Service -> Handler)
+	repos := repository.NewRepository(db, redisClient, repository.Config{CacheTTL: cfg.CacheTTL})
+
+	// Проверки местоположения пишутся пачками в фоне, а не INSERT'ом в каждом запросе
+	var checkBuffer *repository.CheckBuffer
+	if cfg.CheckBuffer {
+		checkBuffer = repository.NewCheckBuffer(repos.Incidents, repository.CheckBufferConfig{
+			FlushSize:     cfg.CheckFlushSize,
+			FlushInterval: cfg.CheckFlushInterval,
+			Limit:         cfg.CheckBufferLimit,
+		})
+		repos.Incidents = checkBuffer
+		go checkBuffer.Run(ctx)
+	}
+	incCfg := service.IncidentConfig{
+		StatsWindow:      cfg.StatsWindow,
+		DetectionRadius:  cfg.DetectionRadius,
+		CoordinateSystem: domain.CoordinateSystem(cfg.CoordinateSystem),
+	}
+	authCfg := service.AuthConfig{
+		LegacyKey: cfg.ApiKey,
+		AdminKey:  cfg.AdminApiKey,
+	}
+	tokenCfg := service.TokenConfig{
+		JWKS:        cfg.JWKSURL,
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		RolesClaim:  cfg.JWTRolesClaim,
+		TenantClaim: cfg.JWTTenantClaim,
+	}
+	rateSpec := cfg.RateLimits
+	if rateSpec == "" {
+		rateSpec = service.DefaultRateLimits
+	}
+	rateLimits, err := service.ParseRateLimits(rateSpec)
+	if err != nil {
+		fatal("invalid RATE_LIMITS", err)
+	}
+	services := service.NewService(repos, incCfg, authCfg, tokenCfg, rateLimits)
+
+	// 3. Инициализация Воркера. Адрес вебхука берется из настроек тенанта задачи
 	// Мы передаем управление WaitGroup внутрь структуры WebhookWorker
-	webhookWorker := worker.NewWebhookWorker(redisClient, cfg.WebhookURL)
+	webhookWorker := worker.NewWebhookWorker(redisClient, cfg.WebhookURL, cfg.WebhookTimeout, services.TenantService)
 
 	// Запускаем фоновые процессы воркера
 	go webhookWorker.RunScheduler(ctx)
-	go webhookWorker.StartAutoscaler(ctx, 2, 10)
+	go webhookWorker.StartAutoscaler(ctx, cfg.WorkersMin, cfg.WorkersMax)
 
-	// 3. Инициализация слоев (Repository -> Service -> Handler)
-	repos := repository.NewRepository(db, redisClient)
-	incCfg := service.IncidentConfig{
-		StatsWindow:     cfg.StatsWindow,
-		DetectionRadius: cfg.DetectionRadius,
-	}
-	services := service.NewService(repos, incCfg)
+	// Суточные партиции location_checks: создание вперед и удаление по LOCATION_CHECKS_RETENTION
+	go worker.NewRetentionJob(repos.Retention, cfg.CheckRetention, cfg.CheckRollup).Run(ctx)
+
+	// Метрики читают пулы и очереди в момент scrape
+	metrics.RegisterPools(db.DB, redisClient)
+	metrics.Registry.MustRegister(webhookWorker.Collector())
 
 	handlers := handler.NewHandler(services, webhookWorker)
 
+	// Радиус, окно статистики и адрес вебхука меняются без рестарта: правка .env или SIGHUP.
+	// Для них .env важнее переменных окружения, без файла SIGHUP перечитывает только окружение процесса,
+	// а оно после старта не меняется
+	go config.Watch(ctx, reloader(*cfg, services, webhookWorker))
+
 	// 4. Запуск HTTP сервера в отдельной горутине
-	srv := new(domain.Server)
+	srv := &domain.Server{ReadTimeout: cfg.HTTPReadTimeout, WriteTimeout: cfg.HTTPWriteTimeout}
 	go func() {
-		if err := srv.Run(cfg.Port, handlers.Routes(cfg.ApiKey)); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("HTTP server error: %v", err)
+		if err := srv.Run(cfg.Port, handlers.Routes()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server error", logging.Err(err))
 		}
 	}()
 
-	log.Printf("Server started on port %s", cfg.Port)
+	slog.Info("server started", "port", cfg.Port)
+
+	// gRPC API поверх тех же сервисов — по желанию, если задан GRPC_PORT
+	var grpcSrv *grpcapi.Server
+	if cfg.GRPCPort != "" {
+		grpcSrv = grpcapi.NewServer(services)
+		go func() {
+			if err := grpcSrv.Run(cfg.GRPCPort); err != nil {
+				slog.Error("gRPC server error", logging.Err(err))
+			}
+		}()
+		slog.Info("gRPC server started", "port", cfg.GRPCPort)
+	}
 
 	// --- ОЖИДАНИЕ ЗАВЕРШЕНИЯ ---
 	<-ctx.Done() // Блокируемся здесь, пока не придет сигнал (SIGINT/SIGTERM)
-	log.Println("Shutting down gracefully...")
+	slog.Info("shutting down gracefully")
+
+	// 0. Снимаем готовность и ждем, пока трафик уйдет на другие поды
+	handlers.SetReady(false)
+	if cfg.ShutdownDrainSeconds > 0 {
+		drain := time.Duration(cfg.ShutdownDrainSeconds) * time.Second
+		slog.Info("draining before shutdown", "delay", drain)
+		time.Sleep(drain)
+	}
 
 	// 1. Останавливаем HTTP-сервер (перестаем принимать новые входящие инциденты)
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	// Shutdown не ждет захваченные WebSocket-соединения: стримы закрываем и дожидаемся сами
+	if err := handlers.CloseStreams(shutdownCtx); err != nil {
+		slog.Warn("location streams did not finish in time", logging.Err(err))
+	}
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Server Shutdown Failed: %+v", err)
+		slog.Error("server shutdown failed", logging.Err(err))
+	}
+	if grpcSrv != nil {
+		if err := grpcSrv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("gRPC server shutdown failed", logging.Err(err))
+		}
+	}
+
+	// Запросы и стримы завершены — дописываем буфер, пока база открыта. Если кто-то не уложился в таймаут,
+	// его проверки после Close пишутся синхронно, а не теряются в буфере
+	if checkBuffer != nil {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		if err := checkBuffer.Close(flushCtx); err == nil {
+			slog.Info("buffered location checks flushed")
+		}
+		flushCancel()
 	}
 
 	// 2. Ждем, пока воркеры доделают задачи, отправят ретраи в Redis и выйдут
-	log.Println("Waiting for workers to finish current tasks...")
+	slog.Info("waiting for workers to finish current tasks")
 
 	// Используем канал для таймаута ожидания воркеров
 	waitCh := make(chan struct{})
@@ -104,18 +225,31 @@ func main() {
 
 	select {
 	case <-waitCh:
-		log.Println("All workers exited cleanly")
-	case <-time.After(15 * time.Second): // Даем воркерам чуть больше времени, чем серверу
-		log.Println("Workers shutdown timed out, force closing resources...")
+		slog.Info("all workers exited cleanly")
+	case <-time.After(cfg.WorkersShutdownTimeout):
+		slog.Warn("workers shutdown timed out, force closing resources")
 	}
 
 	// 3. Только когда воркеры закончили работу с БД/Redis, закрываем соединения
 	if err := redisClient.Close(); err != nil {
-		log.Printf("Failed to close Redis: %v", err)
+		slog.Error("failed to close Redis", logging.Err(err))
 	}
 	if err := db.Close(); err != nil {
-		log.Printf("Failed to close DB: %v", err)
+		slog.Error("failed to close DB", logging.Err(err))
 	}
 
-	fmt.Println("Server exited properly")
+	// Досылаем накопленные спаны
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("failed to flush traces", logging.Err(err))
+	}
+
+	slog.Info("server exited properly")
+}
+
+// fatal — log.Fatal для slog: ошибка старта, дальше работать нельзя
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Err(err))
+	os.Exit(1)
 }
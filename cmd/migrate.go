@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/ArtemChadaev/RedGo/internal/config"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/repository"
+	"github.com/ArtemChadaev/RedGo/migrate"
+)
+
+const migrateUsage = `usage: server migrate <command>
+  up          apply all pending migrations
+  down [N]    revert the last N migrations (default 1)
+  status      show the database version and applied migrations
+  verify      on an empty database check that every up/down pair round-trips
+  baseline V  mark a legacy database created without version tracking as migrated up to V without running SQL`
+
+var errMigrateUsage = errors.New("unknown migrate command")
+
+// migrateCommand — разобранные аргументы подкоманды. n — число шагов для down или версия для baseline
+type migrateCommand struct {
+	name string
+	n    int64
+}
+
+// parseMigrateArgs проверяет аргументы до подключения к базе: опечатка не должна ждать таймаута Postgres
+func parseMigrateArgs(args []string) (migrateCommand, error) {
+	if len(args) == 0 {
+		return migrateCommand{}, errMigrateUsage
+	}
+
+	cmd := migrateCommand{name: args[0]}
+	switch cmd.name {
+	case "up", "status", "verify":
+		if len(args) > 1 {
+			return migrateCommand{}, errMigrateUsage
+		}
+
+	case "down":
+		cmd.n = 1
+		if len(args) > 2 {
+			return migrateCommand{}, errMigrateUsage
+		}
+		if len(args) == 2 {
+			steps, err := strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return migrateCommand{}, fmt.Errorf("down: %q is not a positive number", args[1])
+			}
+			cmd.n = int64(steps)
+		}
+
+	case "baseline":
+		if len(args) != 2 {
+			return migrateCommand{}, errMigrateUsage
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 1 {
+			return migrateCommand{}, fmt.Errorf("baseline: %q is not a positive version", args[1])
+		}
+		cmd.n = version
+
+	default:
+		return migrateCommand{}, errMigrateUsage
+	}
+	return cmd, nil
+}
+
+// runMigrate — подкоманда migrate. Нужен только конфиг Postgres, остальные ключи не проверяются
+func runMigrate(args []string) error {
+	cmd, err := parseMigrateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	if err := logging.Setup(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := repository.NewPostgresDB(postgresConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := repository.NewMigrator(db, migrate.Files)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.name {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migrations\n", n)
+
+	case "down":
+		n, err := migrator.Down(ctx, int(cmd.n))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migrations\n", n)
+
+	case "status":
+		version, list, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("database version: %d\n", version)
+		for _, m := range list {
+			mark := "pending"
+			if m.Applied {
+				mark = "applied"
+			}
+			fmt.Printf("%06d_%s\t%s\n", m.Version, m.Name, mark)
+		}
+
+	case "verify":
+		if err := migrator.Verify(ctx); err != nil {
+			return err
+		}
+		fmt.Println("all migrations round-trip")
+
+	case "baseline":
+		if err := migrator.Baseline(ctx, cmd.n); err != nil {
+			return err
+		}
+		fmt.Printf("database marked as migrated up to version %d\n", cmd.n)
+	}
+	return nil
+}
+
+func postgresConfig(cfg *config.Config) repository.PostgresConfig {
+	return repository.PostgresConfig{
+		Host:     cfg.DBHost,
+		Port:     cfg.DBPort,
+		Username: cfg.DBUser,
+		Database: cfg.DBName,
+		Password: cfg.DBPassword,
+		SSLMode:  cfg.DBSSLMode,
+	}
+}
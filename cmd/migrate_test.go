@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMigrateArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want migrateCommand
+		err  string // пусто — без ошибки, usage — errMigrateUsage
+	}{
+		{args: []string{"up"}, want: migrateCommand{name: "up"}},
+		{args: []string{"status"}, want: migrateCommand{name: "status"}},
+		{args: []string{"verify"}, want: migrateCommand{name: "verify"}},
+		{args: []string{"down"}, want: migrateCommand{name: "down", n: 1}},
+		{args: []string{"down", "3"}, want: migrateCommand{name: "down", n: 3}},
+		{args: []string{"baseline", "7"}, want: migrateCommand{name: "baseline", n: 7}},
+
+		{args: nil, err: "usage"},
+		{args: []string{"sideways"}, err: "usage"},
+		{args: []string{"up", "now"}, err: "usage"},
+		{args: []string{"down", "1", "2"}, err: "usage"},
+		{args: []string{"baseline"}, err: "usage"},
+		{args: []string{"down", "0"}, err: "not a positive number"},
+		{args: []string{"down", "all"}, err: "not a positive number"},
+		{args: []string{"baseline", "-1"}, err: "not a positive version"},
+		{args: []string{"baseline", "v7"}, err: "not a positive version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			got, err := parseMigrateArgs(tt.args)
+			switch {
+			case tt.err == "":
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("got %+v, want %+v", got, tt.want)
+				}
+			case tt.err == "usage":
+				if !errors.Is(err, errMigrateUsage) {
+					t.Errorf("err = %v, want usage", err)
+				}
+			default:
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunMigrateChecksArgsFirst(t *testing.T) {
+	// Без конфига Postgres ошибка аргументов все равно видна сразу, а не после подключения
+	t.Chdir(t.TempDir())
+	t.Setenv("DB_HOST", "")
+	if err := runMigrate([]string{"sideways"}); !errors.Is(err, errMigrateUsage) {
+		t.Errorf("err = %v, want usage", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/ArtemChadaev/RedGo/internal/config"
+	"github.com/ArtemChadaev/RedGo/internal/service"
+	"github.com/ArtemChadaev/RedGo/internal/worker"
+)
+
+// reloader применяет к работающему сервису безопасные изменения конфига. current — действующие значения:
+// в него переносятся только примененные ключи, поэтому отклоненное изменение напоминает о себе до рестарта
+func reloader(current config.Config, services *service.Service, webhookWorker *worker.WebhookWorker) func(*config.Config) {
+	return func(next *config.Config) {
+		live, restart := config.Changes(&current, next)
+		if len(restart) > 0 {
+			slog.Warn("config changes rejected: restart required to apply", "keys", restart)
+		}
+		if len(live) == 0 {
+			return
+		}
+
+		current.DetectionRadius = next.DetectionRadius
+		current.StatsWindow = next.StatsWindow
+		current.WebhookURL = next.WebhookURL
+
+		services.UpdateIncidentConfig(service.IncidentConfig{
+			StatsWindow:     current.StatsWindow,
+			DetectionRadius: current.DetectionRadius,
+		})
+		webhookWorker.SetWebhookURL(current.WebhookURL)
+
+		// Через ту же маскировку, что и лог старта: секреты не попадут в лог, даже если станут live
+		slog.Info("config reloaded", "applied", live, "config", current.LogKeys(live...))
+	}
+}
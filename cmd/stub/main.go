@@ -3,11 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"os"
 
+	"github.com/ArtemChadaev/RedGo/internal/logging"
 	"github.com/joho/godotenv"
 	"golang.ngrok.com/ngrok/v2"
 )
@@ -15,11 +16,12 @@ import (
 func main() {
 	// Загружаем переменные из .env
 	if err := godotenv.Load(); err != nil {
-		log.Println("Предупреждение: .env файл не найден")
+		slog.Warn(".env file not found")
 	}
 
 	if err := run(context.Background()); err != nil {
-		log.Fatal(err)
+		slog.Error("stub stopped", logging.Err(err))
+		os.Exit(1)
 	}
 }
 
@@ -37,18 +39,21 @@ func run(ctx context.Context) error {
 			return
 		}
 
+		// По этим ID доставку можно найти в логах сервиса
+		logger := slog.With("delivery_id", r.Header.Get("X-Delivery-ID"), "request_id", r.Header.Get("X-Request-ID"))
+
 		chance := rand.Intn(100)
 		switch {
 		case chance < 10:
-			fmt.Println("Имитация зависания")
+			logger.Info("simulating hang")
 			<-make(chan struct{})
 
 		case chance < 20:
-			fmt.Println("Ответ: 500")
+			logger.Info("responding", "status", http.StatusInternalServerError)
 			w.WriteHeader(http.StatusInternalServerError)
 
 		default:
-			fmt.Println("Ответ: 200")
+			logger.Info("responding", "status", http.StatusOK)
 			w.WriteHeader(http.StatusOK)
 		}
 	})
@@ -56,13 +61,13 @@ func run(ctx context.Context) error {
 	// Фоновое прослушивание 9090
 	go func() {
 		localAddr := ":9090"
-		fmt.Println("localhost" + localAddr)
+		slog.Info("listening", "addr", "localhost"+localAddr)
 		if err := http.ListenAndServe(localAddr, handler); err != nil {
-			log.Printf("Ошибка локального сервера: %v", err)
+			slog.Error("local server error", logging.Err(err))
 		}
 	}()
 
-	fmt.Println("Подключаемся к ngrok")
+	slog.Info("connecting to ngrok")
 	agent, err := ngrok.NewAgent(ngrok.WithAuthtoken(token))
 	if err != nil {
 		return fmt.Errorf("Ошибка создания агента: %w", err)
@@ -73,7 +78,7 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("Ошибка Listen: %w", err)
 	}
 
-	fmt.Println("URL:", ln.URL())
+	slog.Info("ngrok tunnel ready", "url", ln.URL().String())
 
 	return http.Serve(ln, handler)
 }
@@ -1,7 +1,8 @@
 package config
 
 import (
-	"log"
+	"log/slog"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -9,51 +10,205 @@ import (
 type Config struct {
 	// Основные настройки приложения
 	Port            string  `mapstructure:"PORT"`
-	ApiKey          string  `mapstructure:"API_KEY"`
+	GRPCPort        string  `mapstructure:"GRPC_PORT"`                   // пусто — gRPC сервер не запускается
+	ApiKey          string  `mapstructure:"API_KEY" secret:"true"`       // общий ключ без админских прав, ключи из базы предпочтительнее
+	AdminApiKey     string  `mapstructure:"ADMIN_API_KEY" secret:"true"` // ключ со scope admin для выпуска ключей, пусто — только ключи из базы
 	StatsWindow     int     `mapstructure:"STATS_TIME_WINDOW_MINUTES"`
 	DetectionRadius float64 `mapstructure:"DETECTION_RADIUS"`
 	WebhookURL      string  `mapstructure:"WEBHOOK_URL"`
 
+	// Таймауты HTTP сервера. WRITE — 0 без ограничения, иначе SSE-стрим рвется по таймауту
+	HTTPReadTimeout  time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+
+	// Доставка вебхуков: таймаут одного запроса и границы автоскейлера воркеров
+	WebhookTimeout time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WorkersMin     int           `mapstructure:"WORKERS_MIN"`
+	WorkersMax     int           `mapstructure:"WORKERS_MAX"`
+
+	// Сколько живет кэш активных инцидентов в Redis
+	CacheTTL time.Duration `mapstructure:"CACHE_TTL"`
+
+	// Срок хранения проверок местоположения, 0 — вечно. Удаляются суточными партициями,
+	// с LOCATION_CHECKS_ROLLUP перед удалением сворачиваются в почасовые агрегаты
+	CheckRetention time.Duration `mapstructure:"LOCATION_CHECKS_RETENTION"`
+	CheckRollup    bool          `mapstructure:"LOCATION_CHECKS_ROLLUP"`
+
+	// Write-behind проверок: копить в памяти и писать пачками по CHECKS_FLUSH_SIZE раз в CHECKS_FLUSH_INTERVAL.
+	// Больше CHECKS_BUFFER_LIMIT в памяти не держим, дальше проверки пишутся синхронно
+	CheckBuffer        bool          `mapstructure:"CHECKS_BUFFER"`
+	CheckFlushSize     int           `mapstructure:"CHECKS_FLUSH_SIZE"`
+	CheckFlushInterval time.Duration `mapstructure:"CHECKS_FLUSH_INTERVAL"`
+	CheckBufferLimit   int           `mapstructure:"CHECKS_BUFFER_LIMIT"`
+
+	// Система координат: game (игровые единицы) или wgs84 (долгота/широта, радиус в метрах)
+	CoordinateSystem string `mapstructure:"COORDINATE_SYSTEM"`
+
+	// Токены операторов от identity provider. JWKS — путь к файлу или URL, пусто — только API-ключи
+	JWKSURL       string `mapstructure:"JWKS_URL"`
+	JWTIssuer     string `mapstructure:"JWT_ISSUER"`
+	JWTAudience   string `mapstructure:"JWT_AUDIENCE"`
+	JWTRolesClaim string `mapstructure:"JWT_ROLES_CLAIM"` // путь к ролям через точку, по умолчанию roles
+	// Claim с тенантом оператора, по умолчанию tenant_id. Токен без него отвергается
+	JWTTenantClaim string `mapstructure:"JWT_TENANT_CLAIM"`
+
+	// Лимиты запросов: "METHOD /path by=limit/window ...; ...", by — key, ip или user.
+	// Пусто — лимиты по умолчанию, off — без лимитов
+	RateLimits string `mapstructure:"RATE_LIMITS"`
+
+	// Трейсы OpenTelemetry: OTLP/HTTP коллектор, пусто — трейсы не отправляются
+	OTLPEndpoint     string  `mapstructure:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTELServiceName  string  `mapstructure:"OTEL_SERVICE_NAME"`
+	TraceSampleRatio float64 `mapstructure:"OTEL_TRACES_SAMPLE_RATIO"` // от 0 до 1, пусто — все трейсы
+
+	// Сколько после сигнала остановки отвечать 503 на /readyz, не закрывая сервер: балансировщик
+	// должен успеть убрать под из ротации. В Kubernetes — чуть больше periodSeconds readiness-пробы
+	ShutdownDrainSeconds int `mapstructure:"SHUTDOWN_DRAIN_SECONDS"`
+	// Сколько ждать остановки HTTP/gRPC серверов и затем воркеров
+	ShutdownTimeout        time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	WorkersShutdownTimeout time.Duration `mapstructure:"WORKERS_SHUTDOWN_TIMEOUT"`
+
+	// Логи: LOG_LEVEL — debug|info|warn|error, LOG_FORMAT — json|text
+	LogLevel  string `mapstructure:"LOG_LEVEL"`
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+
 	// Настройки Postgres
 	DBHost     string `mapstructure:"DB_HOST"`
 	DBPort     string `mapstructure:"DB_PORT"`
 	DBUser     string `mapstructure:"DB_USER"`
 	DBName     string `mapstructure:"DB_NAME"`
-	DBPassword string `mapstructure:"DB_PASSWORD"`
+	DBPassword string `mapstructure:"DB_PASSWORD" secret:"true"`
+	DBSSLMode  string `mapstructure:"DB_SSLMODE"`
+	// Применять миграции при старте. false — только подкомандой migrate
+	MigrateOnStart bool `mapstructure:"MIGRATE_ON_START"`
 
 	// Настройки Redis
 	RedisHost     string `mapstructure:"REDIS_HOST"`
 	RedisPort     string `mapstructure:"REDIS_PORT"`
-	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
+	RedisPassword string `mapstructure:"REDIS_PASSWORD" secret:"true"`
+	RedisPoolSize int    `mapstructure:"REDIS_POOL_SIZE"`
 }
 
-func Load() (*Config, error) {
-	v := viper.New()
-	log.Println("Loading config...")
+// defaults — все ключи конфига со значениями по умолчанию. "" — значения нет, ключ обязательный
+// или необязательный по смыслу, это решает Validate
+var defaults = map[string]any{
+	"PORT":                        "8080",
+	"GRPC_PORT":                   "",
+	"API_KEY":                     "",
+	"ADMIN_API_KEY":               "",
+	"STATS_TIME_WINDOW_MINUTES":   10,
+	"DETECTION_RADIUS":            "",
+	"WEBHOOK_URL":                 "",
+	"HTTP_READ_TIMEOUT":           10 * time.Second,
+	"HTTP_WRITE_TIMEOUT":          10 * time.Second,
+	"WEBHOOK_TIMEOUT":             5 * time.Second,
+	"WORKERS_MIN":                 2,
+	"WORKERS_MAX":                 10,
+	"CACHE_TTL":                   10 * time.Minute,
+	"LOCATION_CHECKS_RETENTION":   30 * 24 * time.Hour,
+	"LOCATION_CHECKS_ROLLUP":      false,
+	"CHECKS_BUFFER":               false,
+	"CHECKS_FLUSH_SIZE":           1000,
+	"CHECKS_FLUSH_INTERVAL":       time.Second,
+	"CHECKS_BUFFER_LIMIT":         100000,
+	"COORDINATE_SYSTEM":           "game",
+	"JWKS_URL":                    "",
+	"JWT_ISSUER":                  "",
+	"JWT_AUDIENCE":                "",
+	"JWT_ROLES_CLAIM":             "roles",
+	"JWT_TENANT_CLAIM":            "tenant_id",
+	"RATE_LIMITS":                 "",
+	"OTEL_EXPORTER_OTLP_ENDPOINT": "",
+	"OTEL_SERVICE_NAME":           "redgo",
+	"OTEL_TRACES_SAMPLE_RATIO":    1.0,
+	"SHUTDOWN_DRAIN_SECONDS":      0,
+	"SHUTDOWN_TIMEOUT":            10 * time.Second,
+	"WORKERS_SHUTDOWN_TIMEOUT":    15 * time.Second,
+	"LOG_LEVEL":                   "info",
+	"LOG_FORMAT":                  "json",
+	"DB_HOST":                     "",
+	"DB_PORT":                     "5432",
+	"DB_USER":                     "",
+	"DB_NAME":                     "",
+	"DB_PASSWORD":                 "",
+	"DB_SSLMODE":                  "disable",
+	"MIGRATE_ON_START":            true,
+	"REDIS_HOST":                  "",
+	"REDIS_PORT":                  "6379",
+	"REDIS_PASSWORD":              "",
+	"REDIS_POOL_SIZE":             250,
+}
 
+func newViper() *viper.Viper {
+	v := viper.New()
 	v.SetConfigName(".env")
 	v.SetConfigType("env")
 	v.AddConfigPath(".")
 	v.AutomaticEnv()
+	return v
+}
+
+// Load читает и проверяет весь конфиг сервиса
+func Load() (*Config, error) {
+	cfg, err := load(true)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadDatabase — конфиг для подкоманды migrate: без WEBHOOK_URL, ключей и Redis
+func LoadDatabase() (*Config, error) {
+	cfg, err := load(true)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateDatabase(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// reload — Load для Watch: тот же конфиг и проверка, но без логов старта на каждое изменение файла
+func reload() (*Config, error) {
+	cfg, err := load(false)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// load собирает конфиг из .env и окружения. verbose — писать в лог, откуда он читается: при старте
+func load(verbose bool) (*Config, error) {
+	v := newViper()
+	if verbose {
+		slog.Info("loading config")
+	}
 
 	// Пытаемся прочитать файл
 	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("Note: .env file not found, using system environment variables")
-		} else {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, err
 		}
+		if verbose {
+			slog.Info(".env file not found, using environment variables")
+		}
+	} else if err := preferFile(v); err != nil {
+		return nil, err
 	}
 
-	// ВАЖНО: Явно привязываем каждый ключ, чтобы Unmarshal сработал без файла
-	keys := []string{
-		"PORT", "API_KEY", "STATS_TIME_WINDOW_MINUTES", "DETECTION_RADIUS",
-		"WEBHOOK_URL", "DB_HOST", "DB_PORT", "DB_USER", "DB_NAME",
-		"DB_PASSWORD", "REDIS_HOST", "REDIS_PORT", "REDIS_PASSWORD",
-	}
-	for _, key := range keys {
-		if err := v.BindEnv(key); err != nil {
-			return nil, err
+	// ВАЖНО: каждый ключ должен быть известен viper, иначе Unmarshal не увидит его без файла.
+	// Пустое значение (KEY= в .env) считаем незаданным: иначе "" не разбирается в число или длительность
+	for key, def := range defaults {
+		v.SetDefault(key, def)
+		if v.GetString(key) == "" {
+			v.Set(key, def)
 		}
 	}
 
@@ -64,3 +219,21 @@ func Load() (*Config, error) {
 
 	return &cfg, nil
 }
+
+// preferFile — для ключей, которые меняются на лету, .env важнее окружения. Иначе правка файла ничего
+// не меняет там, где те же ключи переданы и переменными (docker-compose передает .env через environment)
+func preferFile(v *viper.Viper) error {
+	file := viper.New()
+	file.SetConfigFile(v.ConfigFileUsed())
+	file.SetConfigType("env")
+	if err := file.ReadInConfig(); err != nil {
+		return err
+	}
+
+	for key := range liveKeys {
+		if value := file.GetString(key); value != "" {
+			v.Set(key, value)
+		}
+	}
+	return nil
+}
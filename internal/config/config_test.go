@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig — конфиг, который проходит Validate; тесты портят в нем по одному полю
+func validConfig() Config {
+	return Config{
+		Port:                   "8080",
+		ApiKey:                 "key",
+		StatsWindow:            10,
+		DetectionRadius:        5,
+		WebhookURL:             "http://hooks.example/incident",
+		HTTPReadTimeout:        10 * time.Second,
+		HTTPWriteTimeout:       10 * time.Second,
+		WebhookTimeout:         5 * time.Second,
+		WorkersMin:             2,
+		WorkersMax:             10,
+		CacheTTL:               10 * time.Minute,
+		CheckRetention:         30 * 24 * time.Hour,
+		CoordinateSystem:       "game",
+		TraceSampleRatio:       1,
+		ShutdownTimeout:        10 * time.Second,
+		WorkersShutdownTimeout: 15 * time.Second,
+		LogLevel:               "info",
+		LogFormat:              "json",
+		DBHost:                 "db",
+		DBPort:                 "5432",
+		DBUser:                 "user",
+		DBName:                 "redgo",
+		DBSSLMode:              "disable",
+		RedisHost:              "redis",
+		RedisPort:              "6379",
+		RedisPoolSize:          250,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	base := validConfig()
+	if err := base.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		key    string // пусто — конфиг валиден
+	}{
+		{"no webhook", func(c *Config) { c.WebhookURL = "" }, "WEBHOOK_URL"},
+		{"relative webhook", func(c *Config) { c.WebhookURL = "/hook" }, "WEBHOOK_URL"},
+		{"port out of range", func(c *Config) { c.Port = "70000" }, "PORT"},
+		{"grpc port optional", func(c *Config) { c.GRPCPort = "" }, ""},
+		{"bad grpc port", func(c *Config) { c.GRPCPort = "grpc" }, "GRPC_PORT"},
+		{"no way to authenticate", func(c *Config) { c.ApiKey = "" }, "API_KEY"},
+		{"jwks instead of keys", func(c *Config) { c.ApiKey, c.JWKSURL = "", "/etc/redgo/jwks.json" }, ""},
+		{"admin key equals api key", func(c *Config) { c.AdminApiKey = c.ApiKey }, "ADMIN_API_KEY"},
+		{"zero radius", func(c *Config) { c.DetectionRadius = 0 }, "DETECTION_RADIUS"},
+		{"unknown coordinate system", func(c *Config) { c.CoordinateSystem = "mercator" }, "COORDINATE_SYSTEM"},
+		{"retention forever", func(c *Config) { c.CheckRetention = 0 }, ""},
+		{"retention under a day", func(c *Config) { c.CheckRetention = time.Hour }, "LOCATION_CHECKS_RETENTION"},
+		{"retention under stats window", func(c *Config) { c.StatsWindow = 3 * 24 * 60; c.CheckRetention = 48 * time.Hour }, "LOCATION_CHECKS_RETENTION"},
+		{"buffer off ignores flush size", func(c *Config) { c.CheckFlushSize = 0 }, ""},
+		{"buffer limit below flush size", func(c *Config) {
+			c.CheckBuffer, c.CheckFlushSize, c.CheckFlushInterval, c.CheckBufferLimit = true, 100, time.Second, 10
+		}, "CHECKS_BUFFER_LIMIT"},
+		{"sample ratio zero", func(c *Config) { c.TraceSampleRatio = 0 }, "OTEL_TRACES_SAMPLE_RATIO"},
+		{"no write timeout", func(c *Config) { c.HTTPWriteTimeout = 0 }, ""},
+		{"zero cache ttl", func(c *Config) { c.CacheTTL = 0 }, "CACHE_TTL"},
+		{"workers max below min", func(c *Config) { c.WorkersMax = 1 }, "WORKERS_MAX"},
+		{"bad log level", func(c *Config) { c.LogLevel = "verbose" }, "LOG_LEVEL"},
+		{"bad ssl mode", func(c *Config) { c.DBSSLMode = "on" }, "DB_SSLMODE"},
+		{"no redis host", func(c *Config) { c.RedisHost = "" }, "REDIS_HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(&c)
+			err := c.Validate()
+			if tt.key == "" {
+				if err != nil {
+					t.Errorf("want valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.key+":") {
+				t.Errorf("err = %v, want problem with %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	c := validConfig()
+	c.WebhookURL = ""
+	c.DBHost = ""
+	c.WorkersMin = 0
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, key := range []string{"WEBHOOK_URL", "DB_HOST", "WORKERS_MIN"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("%v: no %s", err, key)
+		}
+	}
+}
+
+func TestValidateDatabase(t *testing.T) {
+	// migrate не нужен ни вебхук, ни Redis
+	c := Config{DBHost: "db", DBPort: "5432", DBUser: "user", DBName: "redgo", DBSSLMode: "require", LogLevel: "debug", LogFormat: "text"}
+	if err := c.ValidateDatabase(); err != nil {
+		t.Errorf("ValidateDatabase: %v", err)
+	}
+
+	c.DBName = ""
+	if err := c.ValidateDatabase(); err == nil || !strings.Contains(err.Error(), "DB_NAME:") {
+		t.Errorf("err = %v, want DB_NAME problem", err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("WEBHOOK_URL", "http://hooks.example/incident")
+	// Пустая переменная — как незаданная, берется значение по умолчанию
+	t.Setenv("WORKERS_MAX", "")
+	t.Setenv("CACHE_TTL", "90s")
+
+	cfg, err := load(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key       string
+		got, want any
+	}{
+		{"WEBHOOK_URL", cfg.WebhookURL, "http://hooks.example/incident"},
+		{"WORKERS_MAX", cfg.WorkersMax, 10},
+		{"CACHE_TTL", cfg.CacheTTL, 90 * time.Second},
+		{"LOCATION_CHECKS_RETENTION", cfg.CheckRetention, 30 * 24 * time.Hour},
+		{"MIGRATE_ON_START", cfg.MigrateOnStart, true},
+		{"OTEL_TRACES_SAMPLE_RATIO", cfg.TraceSampleRatio, 1.0},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLogValueMasksSecrets(t *testing.T) {
+	c := validConfig()
+	c.DBPassword = "hunter2"
+
+	got := make(map[string]string)
+	for _, a := range c.LogKeys("API_KEY", "DB_PASSWORD", "REDIS_PASSWORD", "CACHE_TTL").Group() {
+		got[a.Key] = a.Value.String()
+	}
+	want := map[string]string{"API_KEY": "***", "DB_PASSWORD": "***", "REDIS_PASSWORD": "", "CACHE_TTL": "10m0s"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	old := validConfig()
+	next := old
+	next.DetectionRadius = 7
+	next.WebhookURL = "http://other.example/hook"
+	next.Port = "9090"
+
+	live, restart := Changes(&old, &next)
+	if !reflect.DeepEqual(live, []string{"DETECTION_RADIUS", "WEBHOOK_URL"}) {
+		t.Errorf("live = %v", live)
+	}
+	if !reflect.DeepEqual(restart, []string{"PORT"}) {
+		t.Errorf("restart = %v", restart)
+	}
+}
+
+func TestLiveKeysPreferFile(t *testing.T) {
+	dir := t.TempDir()
+	env := "DETECTION_RADIUS=20\nWEBHOOK_URL=\nPORT=9090\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(env), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	t.Setenv("DETECTION_RADIUS", "15")
+	t.Setenv("WEBHOOK_URL", "http://env.example/hook")
+	t.Setenv("PORT", "8081")
+
+	cfg, err := load(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key       string
+		got, want any
+	}{
+		// live-ключ из файла важнее окружения
+		{"DETECTION_RADIUS", cfg.DetectionRadius, 20.0},
+		// пустое значение в файле не перекрывает окружение
+		{"WEBHOOK_URL", cfg.WebhookURL, "http://env.example/hook"},
+		// остальные ключи — как раньше, окружение важнее
+		{"PORT", cfg.Port, "8081"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// problems копит ошибки проверки, чтобы вернуть все разом, а не первую найденную
+type problems []string
+
+func (p *problems) add(key, format string, args ...any) {
+	*p = append(*p, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (p *problems) port(key, value string, required bool) {
+	if value == "" {
+		if required {
+			p.add(key, "required")
+		}
+		return
+	}
+	if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+		p.add(key, "%q is not a port number", value)
+	}
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	// Ключи из map обходятся в случайном порядке, сортируем для стабильного вывода
+	slices.Sort(p)
+	return fmt.Errorf("invalid config: %s", strings.Join(p, "; "))
+}
+
+// Validate проверяет конфиг целиком и возвращает все проблемы одной ошибкой
+func (c *Config) Validate() error {
+	var p problems
+	c.checkDatabase(&p)
+	c.checkLogging(&p)
+
+	p.port("PORT", c.Port, true)
+	p.port("GRPC_PORT", c.GRPCPort, false)
+	p.port("REDIS_PORT", c.RedisPort, true)
+
+	if c.WebhookURL == "" {
+		p.add("WEBHOOK_URL", "required")
+	} else if !isHTTPURL(c.WebhookURL) {
+		p.add("WEBHOOK_URL", "%q is not an absolute http(s) URL", c.WebhookURL)
+	}
+	if c.JWKSURL != "" && strings.Contains(c.JWKSURL, "://") && !isHTTPURL(c.JWKSURL) {
+		p.add("JWKS_URL", "%q is not an absolute http(s) URL or file path", c.JWKSURL)
+	}
+	if c.OTLPEndpoint != "" && !isHTTPURL(c.OTLPEndpoint) {
+		p.add("OTEL_EXPORTER_OTLP_ENDPOINT", "%q is not an absolute http(s) URL", c.OTLPEndpoint)
+	}
+
+	// Без единого способа авторизации API закрыт для всех, кроме ключей, которые некому выпустить
+	if c.ApiKey == "" && c.AdminApiKey == "" && c.JWKSURL == "" {
+		p.add("API_KEY", "set API_KEY, ADMIN_API_KEY or JWKS_URL, otherwise no one can authenticate")
+	}
+	if c.ApiKey != "" && c.ApiKey == c.AdminApiKey {
+		p.add("ADMIN_API_KEY", "must differ from API_KEY")
+	}
+
+	if c.StatsWindow < 1 {
+		p.add("STATS_TIME_WINDOW_MINUTES", "must be at least 1, got %d", c.StatsWindow)
+	}
+	if c.DetectionRadius <= 0 {
+		p.add("DETECTION_RADIUS", "must be greater than 0, got %v", c.DetectionRadius)
+	}
+	switch c.CoordinateSystem {
+	case "game", "wgs84":
+	default:
+		p.add("COORDINATE_SYSTEM", "%q: want game or wgs84", c.CoordinateSystem)
+	}
+
+	// Короче суток не имеет смысла: удаляются целые сутки. И не короче окна статистики
+	if c.CheckRetention < 0 || c.CheckRetention > 0 && c.CheckRetention < 24*time.Hour {
+		p.add("LOCATION_CHECKS_RETENTION", "must be 0 (keep forever) or at least 24h, got %s", c.CheckRetention)
+	} else if c.CheckRetention > 0 && c.CheckRetention < time.Duration(c.StatsWindow)*time.Minute {
+		p.add("LOCATION_CHECKS_RETENTION", "must cover STATS_TIME_WINDOW_MINUTES (%d), got %s", c.StatsWindow, c.CheckRetention)
+	}
+
+	if c.CheckBuffer {
+		if c.CheckFlushSize < 1 {
+			p.add("CHECKS_FLUSH_SIZE", "must be at least 1, got %d", c.CheckFlushSize)
+		}
+		if c.CheckFlushInterval <= 0 {
+			p.add("CHECKS_FLUSH_INTERVAL", "must be greater than 0, got %s", c.CheckFlushInterval)
+		}
+		if c.CheckBufferLimit < c.CheckFlushSize {
+			p.add("CHECKS_BUFFER_LIMIT", "must be at least CHECKS_FLUSH_SIZE (%d), got %d", c.CheckFlushSize, c.CheckBufferLimit)
+		}
+	}
+
+	if c.TraceSampleRatio <= 0 || c.TraceSampleRatio > 1 {
+		p.add("OTEL_TRACES_SAMPLE_RATIO", "must be in (0, 1], got %v", c.TraceSampleRatio)
+	}
+
+	if c.RedisHost == "" {
+		p.add("REDIS_HOST", "required")
+	}
+	if c.RedisPoolSize < 1 {
+		p.add("REDIS_POOL_SIZE", "must be at least 1, got %d", c.RedisPoolSize)
+	}
+
+	for key, d := range map[string]time.Duration{
+		"HTTP_READ_TIMEOUT":        c.HTTPReadTimeout,
+		"WEBHOOK_TIMEOUT":          c.WebhookTimeout,
+		"CACHE_TTL":                c.CacheTTL,
+		"SHUTDOWN_TIMEOUT":         c.ShutdownTimeout,
+		"WORKERS_SHUTDOWN_TIMEOUT": c.WorkersShutdownTimeout,
+	} {
+		if d <= 0 {
+			p.add(key, "must be greater than 0, got %s", d)
+		}
+	}
+	if c.HTTPWriteTimeout < 0 {
+		p.add("HTTP_WRITE_TIMEOUT", "must not be negative, got %s", c.HTTPWriteTimeout)
+	}
+	if c.ShutdownDrainSeconds < 0 {
+		p.add("SHUTDOWN_DRAIN_SECONDS", "must not be negative, got %d", c.ShutdownDrainSeconds)
+	}
+
+	if c.WorkersMin < 1 {
+		p.add("WORKERS_MIN", "must be at least 1, got %d", c.WorkersMin)
+	}
+	if c.WorkersMax < c.WorkersMin {
+		p.add("WORKERS_MAX", "must be at least WORKERS_MIN (%d), got %d", c.WorkersMin, c.WorkersMax)
+	}
+
+	return p.err()
+}
+
+// ValidateDatabase проверяет только то, что нужно подкоманде migrate: Postgres и логи
+func (c *Config) ValidateDatabase() error {
+	var p problems
+	c.checkDatabase(&p)
+	c.checkLogging(&p)
+	return p.err()
+}
+
+func (c *Config) checkDatabase(p *problems) {
+	p.port("DB_PORT", c.DBPort, true)
+	for key, value := range map[string]string{"DB_HOST": c.DBHost, "DB_USER": c.DBUser, "DB_NAME": c.DBName} {
+		if value == "" {
+			p.add(key, "required")
+		}
+	}
+	switch c.DBSSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		p.add("DB_SSLMODE", "%q: want disable, allow, prefer, require, verify-ca or verify-full", c.DBSSLMode)
+	}
+}
+
+func (c *Config) checkLogging(p *problems) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		p.add("LOG_LEVEL", "%q: want debug, info, warn or error", c.LogLevel)
+	}
+	switch strings.ToLower(c.LogFormat) {
+	case "json", "text":
+	default:
+		p.add("LOG_FORMAT", "%q: want json or text", c.LogFormat)
+	}
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// LogValue — действующий конфиг для лога старта. Поля с тегом secret маскируются,
+// пустой секрет остается пустым, чтобы было видно, что он не задан
+func (c Config) LogValue() slog.Value {
+	return c.logValue(nil)
+}
+
+// LogKeys — как LogValue, но только перечисленные ключи: лог перезагрузки показывает примененные значения
+func (c Config) LogKeys(keys ...string) slog.Value {
+	only := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		only[k] = true
+	}
+	return c.logValue(only)
+}
+
+// logValue собирает поля конфига с маскировкой секретов. only == nil — все поля
+func (c Config) logValue(only map[string]bool) slog.Value {
+	v := reflect.ValueOf(c)
+	t := v.Type()
+
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if only != nil && !only[key] {
+			continue
+		}
+
+		switch {
+		case field.Tag.Get("secret") == "true":
+			masked := ""
+			if !value.IsZero() {
+				masked = "***"
+			}
+			attrs = append(attrs, slog.String(key, masked))
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			attrs = append(attrs, slog.String(key, time.Duration(value.Int()).String()))
+		default:
+			attrs = append(attrs, slog.Any(key, value.Interface()))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/fsnotify/fsnotify"
+)
+
+// liveKeys — настройки, которые применяются без рестарта. Остальные изменения отклоняются до перезапуска.
+// Их значение из .env важнее переменных окружения, см. preferFile
+var liveKeys = map[string]bool{
+	"DETECTION_RADIUS":          true,
+	"STATS_TIME_WINDOW_MINUTES": true,
+	"WEBHOOK_URL":               true,
+}
+
+// reloadDebounce — редактор сохраняет файл несколькими событиями, перечитываем один раз
+const reloadDebounce = 300 * time.Millisecond
+
+// Watch перечитывает конфиг при изменении .env и по SIGHUP. onChange получает только конфиг,
+// прошедший Validate; невалидный отклоняется с ошибкой в логе, и продолжает действовать старый.
+// Вызовы onChange идут из одной горутины, блокируется до отмены ctx
+func Watch(ctx context.Context, onChange func(*Config)) {
+	trigger := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Без .env следить не за чем, остается SIGHUP для переменных окружения
+	v := newViper()
+	if err := v.ReadInConfig(); err == nil {
+		v.OnConfigChange(func(fsnotify.Event) { notify() })
+		v.WatchConfig()
+		slog.Info("watching config file", "path", v.ConfigFileUsed())
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("SIGHUP received, reloading config")
+		case <-trigger:
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reloadDebounce):
+			}
+			select {
+			case <-trigger:
+			default:
+			}
+		}
+
+		cfg, err := reload()
+		if err != nil {
+			slog.Error("config reload rejected", logging.Err(err))
+			continue
+		}
+		onChange(cfg)
+	}
+}
+
+// Changes сравнивает конфиги и делит измененные ключи на применимые на лету и требующие рестарта
+func Changes(old, next *Config) (live, restart []string) {
+	ov, nv := reflect.ValueOf(*old), reflect.ValueOf(*next)
+	t := ov.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if ov.Field(i).Interface() == nv.Field(i).Interface() {
+			continue
+		}
+		key := t.Field(i).Tag.Get("mapstructure")
+		if liveKeys[key] {
+			live = append(live, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	return live, restart
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AuditRecord — кто и что сделал с инцидентом. Incident — состояние после изменения
+type AuditRecord struct {
+	ID         int64             `json:"id"`
+	IncidentID int               `json:"incident_id"`
+	Action     IncidentEventType `json:"action"`
+	ActorID    string            `json:"actor_id"`
+	ActorName  string            `json:"actor_name"`
+	ActorKind  string            `json:"actor_kind"`
+	Incident   Incident          `json:"incident"`
+	At         time.Time         `json:"at"`
+}
+
+type AuditRepository interface {
+	Save(ctx context.Context, rec AuditRecord) error
+	// ListByIncident — записи от новых к старым. Работает и для окончательно удаленных инцидентов
+	ListByIncident(ctx context.Context, incidentID int, limit int) ([]AuditRecord, error)
+}
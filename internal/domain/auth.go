@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Scope — право на группу операций. Ключ с admin может всё
+type Scope string
+
+const (
+	ScopeIncidentsRead  Scope = "incidents:read"
+	ScopeIncidentsWrite Scope = "incidents:write"
+	ScopeLocationCheck  Scope = "location:check"
+	ScopeAdmin          Scope = "admin"
+)
+
+const (
+	PrincipalAPIKey   = "api_key"
+	PrincipalOperator = "operator" // человек с токеном от identity provider
+)
+
+// RoleScopes — роли оператора из JWT и права, которые они дают
+var RoleScopes = map[string][]Scope{
+	"admin":    {ScopeAdmin},
+	"operator": {ScopeIncidentsRead, ScopeIncidentsWrite},
+	"viewer":   {ScopeIncidentsRead},
+}
+
+// Principal — тот, от чьего имени выполняется запрос
+type Principal struct {
+	ID       string // стабильный идентификатор: "key:42", "env:API_KEY"
+	Name     string // человекочитаемое имя для логов и аудита
+	Kind     string // api_key или operator
+	TenantID string
+	Scopes   []Scope
+}
+
+// IsPlatformAdmin — admin тенанта хостинга: управляет тенантами и ключами всех тенантов
+func (p *Principal) IsPlatformAdmin() bool {
+	return p != nil && p.TenantID == DefaultTenantID && p.HasScope(ScopeAdmin)
+}
+
+func (p *Principal) HasScope(scope Scope) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal кладет автора запроса в контекст, чтобы сервисы могли его использовать
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom достает автора запроса, nil — анонимный вызов
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// APIKey — выданный ключ. Сам ключ не хранится, только его хэш
+type APIKey struct {
+	ID         int        `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // первые символы ключа, чтобы узнать его в списке
+	Scopes     []Scope    `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyInput struct {
+	// TenantID — чей ключ. Пусто — тенант того, кто выпускает; чужой может указать только platform admin
+	TenantID  string     `json:"tenant_id" binding:"omitempty,max=64"`
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []Scope    `json:"scopes" binding:"required,min=1,dive,oneof=incidents:read incidents:write location:check admin"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey, hash string) error
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	// List и Revoke с пустым tenantID работают по всем тенантам
+	List(ctx context.Context, tenantID string) ([]APIKey, error)
+	Revoke(ctx context.Context, id int, tenantID string) error
+	// Touch обновляет last_used_at
+	Touch(ctx context.Context, id int) error
+}
+
+// APIKeyUsageRepository — счетчики запросов по ключам и маршрутам, по дням
+type APIKeyUsageRepository interface {
+	Incr(ctx context.Context, principalID, route string) error
+	// Get возвращает usage[день][маршрут] за последние days дней
+	Get(ctx context.Context, principalID string, days int) (map[string]map[string]int64, error)
+}
+
+type APIKeyService interface {
+	// Authenticate проверяет ключ из заголовка и возвращает его владельца
+	Authenticate(ctx context.Context, rawKey string) (*Principal, error)
+	// CreateKey выдает новый ключ. Сырой ключ возвращается только здесь
+	CreateKey(ctx context.Context, input CreateAPIKeyInput) (*APIKey, string, error)
+	ListKeys(ctx context.Context) ([]APIKey, error)
+	RevokeKey(ctx context.Context, id int) error
+
+	RecordUsage(ctx context.Context, p *Principal, route string)
+	KeyUsage(ctx context.Context, id int, days int) (map[string]map[string]int64, error)
+}
+
+// TokenService проверяет bearer-токены операторов (JWT от identity provider)
+type TokenService interface {
+	// Enabled — настроен ли JWKS. Без него bearer-токены не принимаются
+	Enabled() bool
+	VerifyToken(ctx context.Context, rawToken string) (*Principal, error)
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Виды ошибок. Репозитории и сервисы возвращают *Error с одним из них в Kind,
+// а транспорт (HTTP, gRPC) по виду выбирает статус ответа
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	// ErrPreconditionFailed — условие запроса (If-Match) не выполнено
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("too many requests")
+)
+
+// Стабильные коды ошибок, на которые могут опираться клиенты
+const (
+	CodeNotFound           = "not_found"
+	CodeIncidentNotFound   = "incident_not_found"
+	CodeValidation         = "validation_failed"
+	CodeInvalidCoordinates = "invalid_coordinates"
+	CodeInvalidBody        = "invalid_body"
+	CodeInvalidID          = "invalid_id"
+	CodeConflict           = "conflict"
+	CodeUnavailable        = "unavailable"
+	CodeUnauthorized       = "unauthorized"
+	CodeVersionMismatch    = "version_mismatch"
+	CodeNotDeleted         = "incident_not_deleted"
+	CodeForbidden          = "forbidden"
+	CodeRateLimited        = "rate_limited"
+	CodeInvalidTenant      = "invalid_tenant"
+	CodeInternal           = "internal"
+)
+
+// FieldError — ошибка конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error — ошибка предметной области: вид, код и человекочитаемое сообщение
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error // исходная причина, наружу не отдается
+	// RetryAfter — через сколько повторить запрос, для ErrRateLimited
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+// Unwrap позволяет errors.Is(err, ErrNotFound) и доступ к исходной причине
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func NewValidationError(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message, Fields: fields}
+}
+
+func NewConflictError(code, message string, cause error) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message, Err: cause}
+}
+
+func NewUnavailableError(message string, cause error) *Error {
+	return &Error{Kind: ErrUnavailable, Code: CodeUnavailable, Message: message, Err: cause}
+}
+
+func NewUnauthorizedError(message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+func NewForbiddenError(message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: CodeForbidden, Message: message}
+}
+
+func NewRateLimitedError(message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: ErrRateLimited, Code: CodeRateLimited, Message: message, RetryAfter: retryAfter}
+}
+
+// ErrVersionMismatch — инцидент изменили после того, как клиент его прочитал
+var ErrVersionMismatch = &Error{
+	Kind:    ErrPreconditionFailed,
+	Code:    CodeVersionMismatch,
+	Message: "incident was modified by someone else, reload it and retry",
+}
+
+// ErrIncidentNotDeleted — восстановить можно только удаленный инцидент
+var ErrIncidentNotDeleted = NewConflictError(CodeNotDeleted, "incident is not deleted", nil)
+
+// ErrIncidentNotFound — общий случай «нет такого инцидента» для всех репозиториев
+var ErrIncidentNotFound = NewNotFoundError(CodeIncidentNotFound, "incident not found")
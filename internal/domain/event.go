@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	IncidentEventsStreamKey  = "incidents:events"      // Redis Stream: история для Last-Event-ID
+	IncidentEventsChannelKey = "incidents:events:live" // Pub/Sub: живые события для всех реплик
+	IncidentEventsMaxLen     = 10000                   // Сколько последних событий хранить для переподключений
+)
+
+type IncidentEventType string
+
+const (
+	EventIncidentCreated       IncidentEventType = "incident.created"
+	EventIncidentUpdated       IncidentEventType = "incident.updated"
+	EventIncidentStatusChanged IncidentEventType = "incident.status_changed"
+	EventIncidentDeleted       IncidentEventType = "incident.deleted"
+	EventIncidentRestored      IncidentEventType = "incident.restored"
+	EventIncidentPurged        IncidentEventType = "incident.purged"
+)
+
+// IncidentEvent — изменение инцидента. ID выдает Redis Stream ("ms-seq"),
+// он же уходит клиенту SSE как id события
+type IncidentEvent struct {
+	ID       string            `json:"id"`
+	Type     IncidentEventType `json:"type"`
+	Incident Incident          `json:"incident"`
+	Actor    string            `json:"actor,omitempty"` // имя ключа или оператора
+	At       time.Time         `json:"at"`
+}
+
+// IncidentEventFilter — фильтры подписки. Пустое поле означает «без фильтра»
+type IncidentEventFilter struct {
+	Status IncidentStatus
+	MapID  string
+}
+
+// Match проверяет, интересно ли событие подписчику
+func (f IncidentEventFilter) Match(ev IncidentEvent) bool {
+	if f.Status != "" && ev.Incident.Status != f.Status {
+		return false
+	}
+	if f.MapID != "" && ev.Incident.MapID != f.MapID {
+		return false
+	}
+	return true
+}
+
+// EventRepository — публикация и доставка событий между репликами
+type EventRepository interface {
+	// Publish сохраняет событие в истории и рассылает его всем подписчикам
+	Publish(ctx context.Context, ev IncidentEvent) error
+	// Since возвращает события после lastID из истории
+	Since(ctx context.Context, lastID string) ([]IncidentEvent, error)
+	// Subscribe отдает канал живых событий. Канал закрывается, когда отменен ctx
+	Subscribe(ctx context.Context) (<-chan IncidentEvent, error)
+}
@@ -0,0 +1,17 @@
+package domain
+
+// CoordinateSystem определяет, как трактовать x/y инцидентов и игроков
+type CoordinateSystem string
+
+const (
+	// CoordinateSystemGame — плоские игровые единицы, евклидово расстояние
+	CoordinateSystemGame CoordinateSystem = "game"
+	// CoordinateSystemWGS84 — x это долгота, y это широта (как в GeoJSON),
+	// расстояние и радиус в метрах
+	CoordinateSystemWGS84 CoordinateSystem = "wgs84"
+)
+
+// NewInvalidCoordinatesError — координата вне допустимого диапазона системы
+func NewInvalidCoordinatesError(field, message string) *Error {
+	return NewValidationError(CodeInvalidCoordinates, "invalid coordinates", FieldError{Field: field, Message: message})
+}
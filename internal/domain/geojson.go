@@ -0,0 +1,93 @@
+package domain
+
+const (
+	GeoJSONPoint             = "Point"
+	GeoJSONFeature           = "Feature"
+	GeoJSONFeatureCollection = "FeatureCollection"
+)
+
+// Geometry — геометрия GeoJSON (RFC 7946). Coordinates зависят от типа:
+// для Point это [x, y]
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// Feature — один объект карты с геометрией и свойствами инцидента
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         int                    `json:"id"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// FeatureCollection — набор объектов, который фронтенд карты отдает прямо в слой
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// ToFeature переводит инцидент в GeoJSON Feature.
+// Если координат нет, geometry будет null — это допустимо по спецификации
+func (inc Incident) ToFeature() Feature {
+	f := Feature{
+		Type: GeoJSONFeature,
+		ID:   inc.ID,
+		Properties: map[string]interface{}{
+			"id":          inc.ID,
+			"description": inc.Description,
+			"status":      inc.Status,
+			"map_id":      inc.MapID,
+		},
+	}
+
+	if inc.X != nil && inc.Y != nil {
+		f.Geometry = &Geometry{
+			Type:        GeoJSONPoint,
+			Coordinates: []float64{*inc.X, *inc.Y},
+		}
+	}
+
+	return f
+}
+
+// ToFeature добавляет к свойствам инцидента расстояние и направление от игрока
+func (n NearbyIncident) ToFeature() Feature {
+	f := n.Incident.ToFeature()
+	f.Properties["distance"] = n.Distance
+	f.Properties["bearing"] = n.Bearing
+	return f
+}
+
+// NewCheckFeatureCollection собирает результат проверки. Предупреждение попадает
+// последним объектом со свойством warning = true
+func NewCheckFeatureCollection(res *LocationCheckResult) FeatureCollection {
+	features := make([]Feature, 0, len(res.Incidents)+1)
+	for _, n := range res.Incidents {
+		features = append(features, n.ToFeature())
+	}
+
+	if res.Warning != nil {
+		f := res.Warning.ToFeature()
+		f.Properties["warning"] = true
+		features = append(features, f)
+	}
+
+	return FeatureCollection{
+		Type:     GeoJSONFeatureCollection,
+		Features: features,
+	}
+}
+
+// NewFeatureCollection собирает FeatureCollection из списка инцидентов
+func NewFeatureCollection(incidents []Incident) FeatureCollection {
+	features := make([]Feature, 0, len(incidents))
+	for _, inc := range incidents {
+		features = append(features, inc.ToFeature())
+	}
+
+	return FeatureCollection{
+		Type:     GeoJSONFeatureCollection,
+		Features: features,
+	}
+}
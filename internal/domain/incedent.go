@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 type IncidentStatus string
@@ -11,12 +12,18 @@ const (
 	StatusInactive IncidentStatus = "inactive"
 )
 
+// DefaultMapID — карта, на которую попадают инциденты и проверки без явного map_id
+const DefaultMapID = "default"
+
 type Incident struct {
 	ID          int            `json:"id" db:"id"`
 	Description string         `json:"description" db:"description"`
 	X           *float64       `json:"x" binding:"required" db:"x"`
 	Y           *float64       `json:"y" binding:"required" db:"y"`
 	Status      IncidentStatus `json:"status" binding:"omitempty,oneof=active inactive" db:"status"`
+	MapID       string         `json:"map_id" binding:"omitempty,max=64" db:"map_id"`
+	Version     int            `json:"version" db:"version"` // растет на каждом изменении, основа ETag
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type UpdateIncidentInput struct {
@@ -24,30 +31,80 @@ type UpdateIncidentInput struct {
 	Y           *float64        `json:"y"`
 	Description *string         `json:"description"`
 	Status      *IncidentStatus `json:"status" binding:"omitempty,oneof=active inactive"`
+	MapID       *string         `json:"map_id" binding:"omitempty,min=1,max=64"`
+}
+
+// IncidentFilter — необязательные фильтры для списка инцидентов.
+// Пустое поле означает «без фильтра»
+type IncidentFilter struct {
+	MapID   string
+	Deleted string // "" — скрыть удаленные, "include" — вместе с ними, "only" — только удаленные
+}
+
+const (
+	DeletedInclude = "include"
+	DeletedOnly    = "only"
+)
+
+// LocationCheck — одна проверка положения игрока на конкретной карте
+type LocationCheck struct {
+	TenantID string  `json:"-" db:"tenant_id"` // заполняет репозиторий из контекста
+	UserID   int     `json:"user_id" db:"user_id"`
+	MapID    string  `json:"map_id" db:"map_id"`
+	X        float64 `json:"x" db:"x"`
+	Y        float64 `json:"y" db:"y"`
 }
 
+// CheckOptions — параметры ответа на проверку, на сохранение проверки не влияют
+type CheckOptions struct {
+	Limit          int  // сколько ближайших совпадений вернуть, 0 — все
+	IncludeNearest bool // вернуть ближайший инцидент за пределами радиуса как предупреждение
+}
+
+// NearbyIncident — инцидент с расстоянием и направлением от игрока
+type NearbyIncident struct {
+	Incident
+	Distance float64 `json:"distance"` // в единицах системы координат (метры для wgs84)
+	Bearing  float64 `json:"bearing"`  // градусы по часовой стрелке, 0 — север / +Y
+}
+
+// LocationCheckResult — совпадения в радиусе, отсортированные от ближнего к дальнему
+type LocationCheckResult struct {
+	Incidents []NearbyIncident `json:"incidents"`
+	Warning   *NearbyIncident  `json:"warning,omitempty"` // ближайший инцидент вне радиуса
+	// Degraded — Postgres недоступен: проверка не сохранена или ответ по последнему известному набору инцидентов
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// IncidentRepository работает только с данными тенанта из TenantFrom(ctx)
 type IncidentRepository interface {
-	Create(ctx context.Context, inc *Incident) error                     // Для POST /
-	GetAll(ctx context.Context, limit, offset int) ([]Incident, error)   // Для GET /
-	GetByID(ctx context.Context, id int) (*Incident, error)              // Для GET /:id
-	Update(ctx context.Context, id int, input UpdateIncidentInput) error // Для PUT /:id
-	Delete(ctx context.Context, id int) error                            // Для DELETE /:id (смена статуса)
+	Create(ctx context.Context, inc *Incident) error                                               // Для POST /
+	GetAll(ctx context.Context, filter IncidentFilter, limit, offset int) ([]Incident, error)      // Для GET /
+	GetByID(ctx context.Context, id int) (*Incident, error)                                        // Для GET /:id
+	Update(ctx context.Context, id int, input UpdateIncidentInput, version int) (*Incident, error) // Для PUT/PATCH /:id, version 0 — без проверки
+	Delete(ctx context.Context, id int, version int) (*Incident, error)                            // Для DELETE /:id (soft delete)
+	Restore(ctx context.Context, id int) (*Incident, error)                                        // Для POST /:id/restore
+	Purge(ctx context.Context, id int) (*Incident, error)                                          // Для DELETE /admin/incidents/:id
 
 	// GetStats Метод для получения количества уникальных пользователей из истории проверок
 	GetStats(ctx context.Context, windowMinutes int) (int, error) // Для GET /stats
 
 	// SaveCheck Сохранение проверок
-	SaveCheck(ctx context.Context, userID int, x, y float64) error
+	SaveCheck(ctx context.Context, check LocationCheck) error
+	// SaveChecks Сохранение пачки проверок multi-row INSERT'ом
+	SaveChecks(ctx context.Context, checks []LocationCheck) error
 
-	// GetAllActive Нужен для получения всех активных записей для кэша
-	GetAllActive(ctx context.Context) ([]Incident, error)
+	// GetAllActive Нужен для получения всех активных записей карты для кэша
+	GetAllActive(ctx context.Context, mapID string) ([]Incident, error)
 
 	PingDB(ctx context.Context) error
 }
 
+// IncidentCacheRepository, как и IncidentRepository, хранит кэш отдельно для каждого тенанта
 type IncidentCacheRepository interface {
-	GetActive(ctx context.Context) ([]Incident, error)
-	SetActive(ctx context.Context, incidents []Incident) error
+	GetActive(ctx context.Context, mapID string) ([]Incident, error)
+	SetActive(ctx context.Context, mapID string, incidents []Incident) error
+	// DeleteActive сбрасывает кэш активных инцидентов сразу для всех карт тенанта
 	DeleteActive(ctx context.Context) error
 
 	PingRedis(ctx context.Context) error
@@ -55,17 +112,30 @@ type IncidentCacheRepository interface {
 
 type IncidentService interface {
 	CreateIncident(ctx context.Context, inc *Incident) error
-	GetIncidents(ctx context.Context, page, pageSize int) ([]Incident, error)
+	GetIncidents(ctx context.Context, filter IncidentFilter, page, pageSize int) ([]Incident, error)
 	GetIncidentByID(ctx context.Context, id int) (*Incident, error)
-	Update(ctx context.Context, id int, input UpdateIncidentInput) error
-	DeleteIncident(ctx context.Context, id int) error
-
-	// CheckLocation Логика проверки координат игрока: попал ли он в радиус опасности
-	CheckLocation(ctx context.Context, userID int, x, y float64) ([]Incident, error)
+	// Update и DeleteIncident с version > 0 выполняются, только если версия совпала (If-Match)
+	Update(ctx context.Context, id int, input UpdateIncidentInput, version int) (*Incident, error)
+	DeleteIncident(ctx context.Context, id int, version int) error
+	RestoreIncident(ctx context.Context, id int) (*Incident, error)
+	// PurgeIncident Окончательное удаление, только для администратора
+	PurgeIncident(ctx context.Context, id int) error
+	// GetAudit История изменений инцидента с авторами
+	GetAudit(ctx context.Context, id int, limit int) ([]AuditRecord, error)
+
+	// CheckLocation Логика проверки координат игрока: попал ли он в радиус опасности на своей карте
+	CheckLocation(ctx context.Context, check LocationCheck, opts CheckOptions) (*LocationCheckResult, error)
+	// CheckLocations Пакетная проверка: результат по user_id. Повтор user_id в пачке — ошибка валидации
+	CheckLocations(ctx context.Context, checks []LocationCheck, opts CheckOptions) (map[int]*LocationCheckResult, error)
 
 	// GetStats Получение статистики (уникальные пользователи)
 	GetStats(ctx context.Context) (int, error)
 
+	// SubscribeEvents Живые события изменений инцидентов со всех реплик
+	SubscribeEvents(ctx context.Context) (<-chan IncidentEvent, error)
+	// EventsSince События из истории после lastID (для Last-Event-ID)
+	EventsSince(ctx context.Context, lastID string) ([]IncidentEvent, error)
+
 	HealthCheckDB(ctx context.Context) error
 	HealthCheckRedis(ctx context.Context) error
 }
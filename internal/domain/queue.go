@@ -2,22 +2,44 @@ package domain
 
 import "context"
 
+// Ключи очередей внутри пространства тенанта, полный ключ — TenantKey(tenant, WebhookQueueKey)
 const (
-	WebhookQueueKey = "webhooks:queue"
-	WebhookDLQKey   = "webhooks:dlq" // Очередь для задач, которые не удалось выполнить
-	MaxRetries      = 5              // Максимальное количество попыток
+	WebhookQueueKey   = "webhooks:queue"
+	WebhookDelayedKey = "webhooks:delayed" // ZSet задач, ждущих повтора
+	WebhookDLQKey     = "webhooks:dlq"     // Очередь для задач, которые не удалось выполнить
+	MaxRetries        = 5                  // Максимальное количество попыток
 )
 
-// WebhookTask представляет данные, которые полетят в очередь Redis
-type WebhookTask struct {
+// WebhookTenantsKey — set тенантов, у которых бывают задачи. По нему воркер находит их очереди
+const WebhookTenantsKey = "webhooks:tenants"
+
+// WebhookPayload — тело вебхука, всё, что видит получатель
+type WebhookPayload struct {
 	IncidentID int     `json:"incident_id"`
 	UserID     int     `json:"user_id"`
+	MapID      string  `json:"map_id"`
 	X          float64 `json:"x"`
 	Y          float64 `json:"y"`
-	Retries    int     `json:"retries"`
+	Distance   float64 `json:"distance"` // расстояние от игрока до инцидента
+}
+
+// WebhookTask представляет данные, которые полетят в очередь Redis: тело вебхука и служебные поля доставки.
+// Payload встроен без тега, поэтому в очереди задача лежит плоским JSON, как и раньше
+type WebhookTask struct {
+	WebhookPayload
+
+	TenantID string `json:"tenant_id"`
+	Retries  int    `json:"retries"`
+	// DeliveryID не меняется между повторами, RequestID — X-Request-ID проверки, породившей задачу
+	DeliveryID string `json:"delivery_id"`
+	RequestID  string `json:"request_id,omitempty"`
+	// TraceContext — traceparent проверки, из которой родилась задача: доставка попадает в тот же трейс
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // QueueRepository — интерфейс для работы с очередью задач
 type QueueRepository interface {
 	PushWebhookTask(ctx context.Context, task WebhookTask) error
+	// PushWebhookTasks кладет пачку задач за один запрос к Redis
+	PushWebhookTasks(ctx context.Context, tasks []WebhookTask) error
 }
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitBy — по чему считается лимит
+type RateLimitBy string
+
+const (
+	RateLimitByKey  RateLimitBy = "key"  // API-ключ или оператор, без них — IP
+	RateLimitByIP   RateLimitBy = "ip"   // адрес клиента
+	RateLimitByUser RateLimitBy = "user" // user_id из тела запроса
+)
+
+// RateLimitRule — не больше Limit запросов за Window на маршрут Route ("POST /api/v1/location/check")
+type RateLimitRule struct {
+	Route  string
+	By     RateLimitBy
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitResult — состояние окна после запроса, из него собираются заголовки X-RateLimit-*
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // когда повторить, если Allowed == false
+	Reset      time.Duration // когда текущее окно закончится
+}
+
+// RateLimitRepository — скользящее окно в Redis
+type RateLimitRepository interface {
+	// Allow засчитывает cost запросов разом, если они укладываются в limit за последние window
+	Allow(ctx context.Context, key string, limit int, window time.Duration, cost int) (RateLimitResult, error)
+}
+
+type RateLimitService interface {
+	// Rules — правила маршрута, пусто — маршрут не ограничен
+	Rules(route string) []RateLimitRule
+	// Allow проверяет правило для subject (ключ, IP или user_id). Отказ тоже возвращается результатом, а не ошибкой
+	Allow(ctx context.Context, rule RateLimitRule, subject string) (RateLimitResult, error)
+	// Apply проверяет все правила маршрута, subject(by) пустой — правило к запросу не относится.
+	// Возвращает самый строгий результат и ошибку rate_limited на первом превышенном правиле
+	Apply(ctx context.Context, route string, subject func(RateLimitBy) string) (*RateLimitResult, error)
+	// Rejections — сколько запросов отклонено, по "маршрут by"
+	Rejections() map[string]int64
+}
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// CheckRetentionRepository — суточные партиции location_checks и их удаление по сроку хранения
+type CheckRetentionRepository interface {
+	// EnsurePartitions создает недостающие суточные партиции до until и переносит в них строки
+	// из партиции по умолчанию. Возвращает имена созданных
+	EnsurePartitions(ctx context.Context, until time.Time) ([]string, error)
+	// DropBefore удаляет партиции, целиком лежащие до before, и такие же строки партиции по умолчанию.
+	// rollup — перед удалением свернуть строки в почасовые агрегаты location_checks_hourly
+	DropBefore(ctx context.Context, before time.Time, rollup bool) (dropped []string, purged int64, err error)
+}
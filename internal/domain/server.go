@@ -8,6 +8,10 @@ import (
 
 type Server struct {
 	httpServer *http.Server
+
+	// ReadTimeout и WriteTimeout из конфига, WriteTimeout 0 — без ограничения
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
 }
 
 func (s *Server) Run(port string, handler http.Handler) error {
@@ -15,8 +19,8 @@ func (s *Server) Run(port string, handler http.Handler) error {
 		Addr:           ":" + port,
 		Handler:        handler,
 		MaxHeaderBytes: 1 << 20,
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
+		ReadTimeout:    s.ReadTimeout,
+		WriteTimeout:   s.WriteTimeout,
 	}
 
 	return s.httpServer.ListenAndServe()
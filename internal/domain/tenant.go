@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTenantID — тенант хостинга. Его ключи с admin управляют остальными тенантами,
+// а его данные лежат в Redis под старыми ключами без префикса
+const DefaultTenantID = "default"
+
+// Tenant — студия, которой мы хостим RedGo. Инциденты, проверки и вебхуки у каждой свои
+type Tenant struct {
+	ID         string    `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	WebhookURL string    `json:"webhook_url,omitempty" db:"webhook_url"` // пусто — общий WEBHOOK_URL
+	RateLimit  int       `json:"rate_limit" db:"rate_limit"`             // проверок местоположения в минуту, 0 — без ограничения
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateTenantInput struct {
+	ID         string `json:"id" binding:"required,max=64"`
+	Name       string `json:"name" binding:"required,max=100"`
+	WebhookURL string `json:"webhook_url" binding:"omitempty,url"`
+	RateLimit  int    `json:"rate_limit" binding:"omitempty,min=0"`
+}
+
+type UpdateTenantInput struct {
+	Name       *string `json:"name" binding:"omitempty,max=100"`
+	WebhookURL *string `json:"webhook_url" binding:"omitempty,max=2048"`
+	RateLimit  *int    `json:"rate_limit" binding:"omitempty,min=0"`
+}
+
+type tenantKey struct{}
+
+// WithTenant задает тенанта явно — для фоновых задач, где нет автора запроса
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFrom — тенант текущего запроса: явно заданный, иначе тенант автора, иначе default
+func TenantFrom(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	if p := PrincipalFrom(ctx); p != nil && p.TenantID != "" {
+		return p.TenantID
+	}
+	return DefaultTenantID
+}
+
+// TenantKey — ключ Redis в пространстве тенанта. У default ключи прежние, чтобы не терять данные при обновлении
+func TenantKey(tenantID, key string) string {
+	if tenantID == "" || tenantID == DefaultTenantID {
+		return key
+	}
+	return "tenant:" + tenantID + ":" + key
+}
+
+type TenantRepository interface {
+	Create(ctx context.Context, t *Tenant) error
+	Get(ctx context.Context, id string) (*Tenant, error)
+	List(ctx context.Context) ([]Tenant, error)
+	Update(ctx context.Context, id string, input UpdateTenantInput) (*Tenant, error)
+}
+
+type TenantService interface {
+	CreateTenant(ctx context.Context, input CreateTenantInput) (*Tenant, error)
+	// GetTenant читает настройки тенанта из кэша в памяти, их дергают на каждую проверку и вебхук
+	GetTenant(ctx context.Context, id string) (*Tenant, error)
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	UpdateTenant(ctx context.Context, id string, input UpdateTenantInput) (*Tenant, error)
+
+	// CheckRateLimit засчитывает checks проверок местоположения в лимит тенанта из контекста.
+	// Пакетная проверка — столько проверок, сколько в ней позиций
+	CheckRateLimit(ctx context.Context, checks int) error
+}
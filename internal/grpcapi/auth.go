@@ -0,0 +1,196 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/pb/redgov1"
+	"github.com/ArtemChadaev/RedGo/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// methodScopes — какое право нужно на каждый метод, как requireScope на маршрутах REST.
+// Метода нет в списке — нужен admin
+var methodScopes = map[string]domain.Scope{
+	redgov1.IncidentService_CreateIncident_FullMethodName: domain.ScopeIncidentsWrite,
+	redgov1.IncidentService_GetIncident_FullMethodName:    domain.ScopeIncidentsRead,
+	redgov1.IncidentService_ListIncidents_FullMethodName:  domain.ScopeIncidentsRead,
+	redgov1.IncidentService_UpdateIncident_FullMethodName: domain.ScopeIncidentsWrite,
+	redgov1.IncidentService_DeleteIncident_FullMethodName: domain.ScopeIncidentsWrite,
+	redgov1.IncidentService_GetStats_FullMethodName:       domain.ScopeIncidentsRead,
+	redgov1.IncidentService_CheckLocation_FullMethodName:  domain.ScopeLocationCheck,
+	redgov1.IncidentService_StreamLocation_FullMethodName: domain.ScopeLocationCheck,
+}
+
+// methodRoutes — под какими маршрутами REST методы gRPC считаются в RATE_LIMITS:
+// одни и те же правила держат оба транспорта
+var methodRoutes = map[string]string{
+	redgov1.IncidentService_CreateIncident_FullMethodName: "POST /api/v1/incidents/",
+	redgov1.IncidentService_GetIncident_FullMethodName:    "GET /api/v1/incidents/:id",
+	redgov1.IncidentService_ListIncidents_FullMethodName:  "GET /api/v1/incidents/",
+	redgov1.IncidentService_UpdateIncident_FullMethodName: "PUT /api/v1/incidents/:id",
+	redgov1.IncidentService_DeleteIncident_FullMethodName: "DELETE /api/v1/incidents/:id",
+	redgov1.IncidentService_GetStats_FullMethodName:       "GET /api/v1/incidents/stats",
+	redgov1.IncidentService_CheckLocation_FullMethodName:  "POST /api/v1/location/check",
+	redgov1.IncidentService_StreamLocation_FullMethodName: "GET /api/v1/location/stream",
+}
+
+func authUnaryInterceptor(services *service.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = withRequestID(ctx)
+		p, err := authorize(ctx, services, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		ctx = domain.WithPrincipal(ctx, p)
+
+		var userID int64
+		if r, ok := req.(interface{ GetUserId() int64 }); ok {
+			userID = r.GetUserId()
+		}
+		if err := rateLimit(ctx, services, info.FullMethod, userID); err != nil {
+			return nil, toStatus(err)
+		}
+
+		resp, err := handler(ctx, req)
+		services.APIKeyService.RecordUsage(context.WithoutCancel(ctx), p, info.FullMethod)
+		return resp, err
+	}
+}
+
+func authStreamInterceptor(services *service.Service) grpc.StreamServerInterceptor {
+	// Лимиты стрим считает сам, на каждое сообщение: открытие потока — еще не проверка
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := withRequestID(ss.Context())
+		p, err := authorize(ctx, services, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		err = handler(srv, &principalStream{ServerStream: ss, ctx: domain.WithPrincipal(ctx, p)})
+		services.APIKeyService.RecordUsage(context.WithoutCancel(ss.Context()), p, info.FullMethod)
+		return err
+	}
+}
+
+// authorize — то же, что requireScope: ключ в metadata x-api-key или токен в authorization
+func authorize(ctx context.Context, services *service.Service, method string) (*domain.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var (
+		p   *domain.Principal
+		err error
+	)
+	if token, ok := bearerToken(firstValue(md, "authorization")); ok {
+		p, err = services.TokenService.VerifyToken(ctx, token)
+	} else {
+		p, err = services.APIKeyService.Authenticate(ctx, firstValue(md, "x-api-key"))
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = domain.ScopeAdmin
+	}
+	if p.Kind == domain.PrincipalOperator {
+		if _, err := services.TenantService.GetTenant(ctx, p.TenantID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				err = domain.NewForbiddenError("unknown tenant " + p.TenantID)
+			}
+			return nil, toStatus(err)
+		}
+	}
+	if !p.HasScope(scope) {
+		return nil, toStatus(domain.NewForbiddenError("api key lacks scope " + string(scope)))
+	}
+
+	return p, nil
+}
+
+// rateLimit — то же, что tenantRateLimit и rateLimit в REST: лимит тенанта для проверок местоположения
+// и правила RATE_LIMITS маршрута. userID 0 — правила user к вызову не относятся
+func rateLimit(ctx context.Context, services *service.Service, method string, userID int64) error {
+	if methodScopes[method] == domain.ScopeLocationCheck {
+		if err := services.TenantService.CheckRateLimit(ctx, 1); err != nil {
+			return err
+		}
+	}
+
+	route, ok := methodRoutes[method]
+	if !ok {
+		return nil
+	}
+
+	_, err := services.RateLimitService.Apply(ctx, route, func(by domain.RateLimitBy) string {
+		switch by {
+		case domain.RateLimitByKey:
+			return domain.PrincipalFrom(ctx).ID
+		case domain.RateLimitByIP:
+			return peerIP(ctx)
+		case domain.RateLimitByUser:
+			if userID != 0 {
+				return strconv.FormatInt(userID, 10)
+			}
+		}
+		return ""
+	})
+	return err
+}
+
+// peerIP — адрес клиента без порта, как ClientIP в REST
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// principalStream подменяет контекст стрима, чтобы обработчик видел автора вызова
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequestID — аналог X-Request-ID для gRPC: metadata x-request-id или новый ID, он же уходит в заголовке ответа
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := firstValue(md, "x-request-id")
+	if !logging.ValidRequestID(id) {
+		id = logging.NewID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+	return logging.WithRequestID(ctx, id)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
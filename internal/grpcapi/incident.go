@@ -0,0 +1,296 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/pb/redgov1"
+	"github.com/ArtemChadaev/RedGo/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type incidentServer struct {
+	redgov1.UnimplementedIncidentServiceServer
+	services *service.Service
+}
+
+func newIncidentServer(services *service.Service) *incidentServer {
+	return &incidentServer{services: services}
+}
+
+func (s *incidentServer) CreateIncident(ctx context.Context, req *redgov1.CreateIncidentRequest) (*redgov1.Incident, error) {
+	inc := domain.Incident{
+		Description: req.GetDescription(),
+		X:           float64Ptr(req.GetX()),
+		Y:           float64Ptr(req.GetY()),
+		Status:      domain.IncidentStatus(req.GetStatus()),
+		MapID:       req.GetMapId(),
+	}
+
+	// Те же правила, что и у binding в REST
+	switch inc.Status {
+	case "":
+		inc.Status = domain.StatusActive
+	case domain.StatusActive, domain.StatusInactive:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "status must be 'active' or 'inactive'")
+	}
+
+	if err := s.services.IncidentService.CreateIncident(ctx, &inc); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProtoIncident(inc), nil
+}
+
+func (s *incidentServer) GetIncident(ctx context.Context, req *redgov1.GetIncidentRequest) (*redgov1.Incident, error) {
+	inc, err := s.services.IncidentService.GetIncidentByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProtoIncident(*inc), nil
+}
+
+func (s *incidentServer) ListIncidents(ctx context.Context, req *redgov1.ListIncidentsRequest) (*redgov1.ListIncidentsResponse, error) {
+	page := int(req.GetPage())
+	if page == 0 {
+		page = 1
+	}
+
+	incidents, err := s.services.IncidentService.GetIncidents(ctx, domain.IncidentFilter{MapID: req.GetMapId()}, page, int(req.GetPageSize()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &redgov1.ListIncidentsResponse{Incidents: make([]*redgov1.Incident, 0, len(incidents))}
+	for _, inc := range incidents {
+		resp.Incidents = append(resp.Incidents, toProtoIncident(inc))
+	}
+
+	return resp, nil
+}
+
+func (s *incidentServer) UpdateIncident(ctx context.Context, req *redgov1.UpdateIncidentRequest) (*redgov1.Incident, error) {
+	input := domain.UpdateIncidentInput{
+		X:           req.X,
+		Y:           req.Y,
+		Description: req.Description,
+		MapID:       req.MapId,
+	}
+	if req.Status != nil {
+		st := domain.IncidentStatus(req.GetStatus())
+		if st != domain.StatusActive && st != domain.StatusInactive {
+			return nil, status.Error(codes.InvalidArgument, "status must be 'active' or 'inactive'")
+		}
+		input.Status = &st
+	}
+
+	if input.X == nil && input.Y == nil && input.Description == nil && input.Status == nil && input.MapID == nil {
+		return nil, status.Error(codes.InvalidArgument, "at least one field (x, y, description, status or map_id) must be provided")
+	}
+
+	if req.GetVersion() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "version must be >= 0")
+	}
+
+	inc, err := s.services.IncidentService.Update(ctx, int(req.GetId()), input, int(req.GetVersion()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProtoIncident(*inc), nil
+}
+
+func (s *incidentServer) DeleteIncident(ctx context.Context, req *redgov1.DeleteIncidentRequest) (*emptypb.Empty, error) {
+	if req.GetVersion() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "version must be >= 0")
+	}
+
+	if err := s.services.IncidentService.DeleteIncident(ctx, int(req.GetId()), int(req.GetVersion())); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *incidentServer) GetStats(ctx context.Context, _ *redgov1.GetStatsRequest) (*redgov1.GetStatsResponse, error) {
+	count, err := s.services.IncidentService.GetStats(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &redgov1.GetStatsResponse{UserCount: int64(count)}, nil
+}
+
+func (s *incidentServer) CheckLocation(ctx context.Context, req *redgov1.CheckLocationRequest) (*redgov1.CheckLocationResponse, error) {
+	resp, err := s.checkLocation(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return resp, nil
+}
+
+// StreamLocation проверяет каждую позицию как CheckLocation. Ошибка одной позиции уходит в ответе
+// с полем error, как сообщение type=error в WebSocket: один игрок с кривыми данными не рвет поток всего сервера
+func (s *incidentServer) StreamLocation(stream redgov1.IncidentService_StreamLocationServer) error {
+	ctx := stream.Context()
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Каждое сообщение — отдельная проверка, как в WebSocket: лимит тенанта и правила маршрута
+		err = rateLimit(ctx, s.services, redgov1.IncidentService_StreamLocation_FullMethodName, req.GetUserId())
+		var resp *redgov1.CheckLocationResponse
+		if err == nil {
+			resp, err = s.checkLocation(ctx, req)
+		}
+		if err != nil {
+			// Клиент ушел или сервер останавливается — отвечать уже некому
+			if ctx.Err() != nil {
+				return toStatus(ctx.Err())
+			}
+			resp = &redgov1.CheckLocationResponse{UserId: req.GetUserId(), Error: toCheckError(err)}
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *incidentServer) checkLocation(ctx context.Context, req *redgov1.CheckLocationRequest) (*redgov1.CheckLocationResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, domain.NewValidationError(domain.CodeValidation, "user_id is required",
+			domain.FieldError{Field: "user_id", Message: "is required"})
+	}
+	if req.GetLimit() < 0 {
+		return nil, domain.NewValidationError(domain.CodeValidation, "limit must be >= 0",
+			domain.FieldError{Field: "limit", Message: "must be >= 0"})
+	}
+
+	res, err := s.services.IncidentService.CheckLocation(ctx, domain.LocationCheck{
+		UserID: int(req.GetUserId()),
+		MapID:  req.GetMapId(),
+		X:      req.GetX(),
+		Y:      req.GetY(),
+	}, domain.CheckOptions{
+		Limit:          int(req.GetLimit()),
+		IncludeNearest: req.GetIncludeNearest(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &redgov1.CheckLocationResponse{
+		UserId:    req.GetUserId(),
+		Incidents: make([]*redgov1.NearbyIncident, 0, len(res.Incidents)),
+		Degraded:  res.Degraded,
+	}
+	for _, n := range res.Incidents {
+		resp.Incidents = append(resp.Incidents, toProtoNearby(n))
+	}
+	if res.Warning != nil {
+		resp.Warning = toProtoNearby(*res.Warning)
+	}
+
+	return resp, nil
+}
+
+// toCheckError — ошибка одной позиции стрима: код и сообщение те же, что вернул бы toStatus
+func toCheckError(err error) *redgov1.CheckError {
+	// toStatus заодно пишет в лог внутренние ошибки
+	code := status.Code(toStatus(err))
+
+	var derr *domain.Error
+	if code == codes.Internal || !errors.As(err, &derr) {
+		return &redgov1.CheckError{Code: domain.CodeInternal, Message: "internal server error"}
+	}
+
+	return &redgov1.CheckError{
+		Code:    derr.Code,
+		Message: derr.Message,
+		// Округляем вверх, как Retry-After в REST
+		RetryAfterSeconds: int64(math.Ceil(derr.RetryAfter.Seconds())),
+	}
+}
+
+// toStatus переводит ошибки сервиса в коды gRPC — по тем же видам, что и HTTP
+func toStatus(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		code = codes.InvalidArgument
+	case errors.Is(err, domain.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, domain.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, domain.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, domain.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, domain.ErrUnavailable):
+		code = codes.Unavailable
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		code = codes.FailedPrecondition
+	case errors.Is(err, domain.ErrRateLimited):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	if code == codes.Internal {
+		slog.Error("grpc request failed", logging.Err(err))
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	// Наружу отдаем только сообщение предметной области, без исходной причины
+	var derr *domain.Error
+	if errors.As(err, &derr) {
+		return status.Error(code, derr.Code+": "+derr.Message)
+	}
+	return status.Error(code, err.Error())
+}
+
+func toProtoIncident(inc domain.Incident) *redgov1.Incident {
+	p := &redgov1.Incident{
+		Id:          int64(inc.ID),
+		Description: inc.Description,
+		Status:      string(inc.Status),
+		MapId:       inc.MapID,
+		Version:     int64(inc.Version),
+	}
+	if inc.X != nil {
+		p.X = *inc.X
+	}
+	if inc.Y != nil {
+		p.Y = *inc.Y
+	}
+	return p
+}
+
+func toProtoNearby(n domain.NearbyIncident) *redgov1.NearbyIncident {
+	return &redgov1.NearbyIncident{
+		Incident: toProtoIncident(n.Incident),
+		Distance: n.Distance,
+		Bearing:  n.Bearing,
+	}
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,58 @@
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/ArtemChadaev/RedGo --go-grpc_out=../.. --go-grpc_opt=module=github.com/ArtemChadaev/RedGo redgo/v1/incident.proto
+
+import (
+	"context"
+	"net"
+
+	"github.com/ArtemChadaev/RedGo/internal/pb/redgov1"
+	"github.com/ArtemChadaev/RedGo/internal/service"
+	"google.golang.org/grpc"
+)
+
+// Server — gRPC-аналог domain.Server: тот же Run/Shutdown, чтобы main завершал оба одинаково
+type Server struct {
+	grpcServer *grpc.Server
+}
+
+func NewServer(services *service.Service) *Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authUnaryInterceptor(services)),
+		grpc.ChainStreamInterceptor(authStreamInterceptor(services)),
+	)
+	redgov1.RegisterIncidentServiceServer(s, newIncidentServer(services))
+
+	return &Server{grpcServer: s}
+}
+
+func (s *Server) Run(port string) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(lis)
+}
+
+// Serve принимает готовый listener, удобно для bufconn и случайного порта
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpcServer.Serve(lis)
+}
+
+// Shutdown ждет завершения текущих вызовов, а по истечении ctx обрывает их
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,618 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/pb/redgov1"
+	"github.com/ArtemChadaev/RedGo/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Ключи тестов и их права
+var testKeys = map[string][]domain.Scope{
+	"reader":  {domain.ScopeIncidentsRead},
+	"writer":  {domain.ScopeIncidentsRead, domain.ScopeIncidentsWrite},
+	"checker": {domain.ScopeLocationCheck},
+}
+
+type fakeKeys struct {
+	domain.APIKeyService
+}
+
+func (fakeKeys) Authenticate(_ context.Context, rawKey string) (*domain.Principal, error) {
+	scopes, ok := testKeys[rawKey]
+	if !ok {
+		return nil, domain.NewUnauthorizedError("invalid api key")
+	}
+	return &domain.Principal{ID: "key:" + rawKey, Name: rawKey, Kind: domain.PrincipalAPIKey, TenantID: domain.DefaultTenantID, Scopes: scopes}, nil
+}
+
+func (fakeKeys) RecordUsage(context.Context, *domain.Principal, string) {}
+
+type fakeTenants struct {
+	domain.TenantService
+	limited bool
+}
+
+func (f *fakeTenants) CheckRateLimit(context.Context, int) error {
+	if f.limited {
+		return domain.NewRateLimitedError("tenant rate limit exceeded", time.Minute)
+	}
+	return nil
+}
+
+// fakeIncidents — инциденты в памяти. CheckLocation находит все инциденты ближе radius
+type fakeIncidents struct {
+	domain.IncidentService
+
+	mu        sync.Mutex
+	nextID    int
+	incidents map[int]domain.Incident
+	degraded  bool
+}
+
+const radius = 10
+
+func newFakeIncidents() *fakeIncidents {
+	return &fakeIncidents{incidents: make(map[int]domain.Incident)}
+}
+
+func (f *fakeIncidents) CreateIncident(_ context.Context, inc *domain.Incident) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	inc.ID, inc.Version = f.nextID, 1
+	if inc.MapID == "" {
+		inc.MapID = domain.DefaultMapID
+	}
+	f.incidents[inc.ID] = *inc
+	return nil
+}
+
+func (f *fakeIncidents) GetIncidentByID(_ context.Context, id int) (*domain.Incident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inc, ok := f.incidents[id]
+	if !ok {
+		return nil, domain.ErrIncidentNotFound
+	}
+	return &inc, nil
+}
+
+func (f *fakeIncidents) GetIncidents(_ context.Context, _ domain.IncidentFilter, _, _ int) ([]domain.Incident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := make([]domain.Incident, 0, len(f.incidents))
+	for _, inc := range f.incidents {
+		list = append(list, inc)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (f *fakeIncidents) Update(_ context.Context, id int, input domain.UpdateIncidentInput, version int) (*domain.Incident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inc, ok := f.incidents[id]
+	if !ok {
+		return nil, domain.ErrIncidentNotFound
+	}
+	if version != 0 && version != inc.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+	if input.Description != nil {
+		inc.Description = *input.Description
+	}
+	if input.X != nil {
+		inc.X = input.X
+	}
+	if input.Status != nil {
+		inc.Status = *input.Status
+	}
+	inc.Version++
+	f.incidents[id] = inc
+	return &inc, nil
+}
+
+func (f *fakeIncidents) DeleteIncident(_ context.Context, id int, version int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inc, ok := f.incidents[id]
+	if !ok {
+		return domain.ErrIncidentNotFound
+	}
+	if version != 0 && version != inc.Version {
+		return domain.ErrVersionMismatch
+	}
+	delete(f.incidents, id)
+	return nil
+}
+
+func (f *fakeIncidents) CheckLocation(_ context.Context, check domain.LocationCheck, _ domain.CheckOptions) (*domain.LocationCheckResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := &domain.LocationCheckResult{Incidents: []domain.NearbyIncident{}, Degraded: f.degraded}
+	for _, inc := range f.incidents {
+		dx, dy := *inc.X-check.X, *inc.Y-check.Y
+		if d := dx*dx + dy*dy; d <= radius*radius {
+			res.Incidents = append(res.Incidents, domain.NearbyIncident{Incident: inc, Distance: d})
+		}
+	}
+	return res, nil
+}
+
+// memRateLimits — RateLimitRepository без Redis, как в тестах REST: счетчик без скользящего окна
+type memRateLimits struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (m *memRateLimits) Allow(_ context.Context, key string, limit int, window time.Duration, cost int) (domain.RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+	if m.counts[key]+cost > limit {
+		return domain.RateLimitResult{Limit: limit, RetryAfter: window, Reset: window}, nil
+	}
+	m.counts[key] += cost
+	return domain.RateLimitResult{Allowed: true, Limit: limit, Remaining: limit - m.counts[key], Reset: window}, nil
+}
+
+type testEnv struct {
+	incidents *fakeIncidents
+	tenants   *fakeTenants
+	client    redgov1.IncidentServiceClient
+}
+
+// newTestEnv поднимает сервер на bufconn: настоящий gRPC-стек с интерсепторами, но без сети.
+// rules — правила RATE_LIMITS, по умолчанию лимитов нет
+func newTestEnv(t *testing.T, rules ...domain.RateLimitRule) *testEnv {
+	t.Helper()
+
+	env := &testEnv{incidents: newFakeIncidents(), tenants: &fakeTenants{}}
+	srv := NewServer(&service.Service{
+		IncidentService:  env.incidents,
+		APIKeyService:    fakeKeys{},
+		TenantService:    env.tenants,
+		RateLimitService: service.NewRateLimitService(&memRateLimits{}, rules),
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	env.client = redgov1.NewIncidentServiceClient(conn)
+	return env
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != code {
+		t.Fatalf("code = %s, want %s (err %v)", got, code, err)
+	}
+}
+
+func TestAuthScopes(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := withKey("writer")
+	created, err := env.client.CreateIncident(ctx, &redgov1.CreateIncidentRequest{Description: "fire", X: 1, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		call func(ctx context.Context) error
+		want codes.Code
+	}{
+		{"no key", "", func(ctx context.Context) error {
+			_, err := env.client.GetIncident(ctx, &redgov1.GetIncidentRequest{Id: created.Id})
+			return err
+		}, codes.Unauthenticated},
+		{"unknown key", "nope", func(ctx context.Context) error {
+			_, err := env.client.GetIncident(ctx, &redgov1.GetIncidentRequest{Id: created.Id})
+			return err
+		}, codes.Unauthenticated},
+		{"reader reads", "reader", func(ctx context.Context) error {
+			_, err := env.client.GetIncident(ctx, &redgov1.GetIncidentRequest{Id: created.Id})
+			return err
+		}, codes.OK},
+		{"reader cannot write", "reader", func(ctx context.Context) error {
+			_, err := env.client.CreateIncident(ctx, &redgov1.CreateIncidentRequest{Description: "x"})
+			return err
+		}, codes.PermissionDenied},
+		{"reader cannot check", "reader", func(ctx context.Context) error {
+			_, err := env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 1})
+			return err
+		}, codes.PermissionDenied},
+		{"checker checks", "checker", func(ctx context.Context) error {
+			_, err := env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 1})
+			return err
+		}, codes.OK},
+		{"checker cannot read incidents", "checker", func(ctx context.Context) error {
+			_, err := env.client.ListIncidents(ctx, &redgov1.ListIncidentsRequest{})
+			return err
+		}, codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = withKey(tt.key)
+			}
+			wantCode(t, tt.call(ctx), tt.want)
+		})
+	}
+}
+
+func TestTenantRateLimitOnChecks(t *testing.T) {
+	env := newTestEnv(t)
+	env.tenants.limited = true
+
+	_, err := env.client.CheckLocation(withKey("checker"), &redgov1.CheckLocationRequest{UserId: 1})
+	wantCode(t, err, codes.ResourceExhausted)
+
+	// Лимит тенанта касается только проверок местоположения
+	_, err = env.client.ListIncidents(withKey("reader"), &redgov1.ListIncidentsRequest{})
+	wantCode(t, err, codes.OK)
+}
+
+func TestTenantRateLimitPerStreamMessage(t *testing.T) {
+	env := newTestEnv(t)
+
+	stream, err := env.client.StreamLocation(withKey("checker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, limited := range []bool{false, true, false} {
+		env.tenants.limited = limited
+		if err := stream.Send(&redgov1.CheckLocationRequest{UserId: 1, X: 1, Y: 1}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("message %d: stream ended: %v", i, err)
+		}
+		if got := resp.GetError().GetCode() == domain.CodeRateLimited; got != limited {
+			t.Errorf("message %d: error = %v, want rate limited %v", i, resp.GetError(), limited)
+		}
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	rules, err := service.ParseRateLimits("POST /api/v1/location/check user=1/1m; " +
+		"GET /api/v1/incidents/ key=1/1m; " +
+		"GET /api/v1/location/stream user=2/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := newTestEnv(t, rules...)
+
+	t.Run("unary user rule", func(t *testing.T) {
+		ctx := withKey("checker")
+		_, err := env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 1})
+		wantCode(t, err, codes.OK)
+		_, err = env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 1})
+		wantCode(t, err, codes.ResourceExhausted)
+		_, err = env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 2})
+		wantCode(t, err, codes.OK)
+	})
+
+	t.Run("unary key rule", func(t *testing.T) {
+		_, err := env.client.ListIncidents(withKey("reader"), &redgov1.ListIncidentsRequest{})
+		wantCode(t, err, codes.OK)
+		_, err = env.client.ListIncidents(withKey("reader"), &redgov1.ListIncidentsRequest{})
+		wantCode(t, err, codes.ResourceExhausted)
+		// Ключи считаются раздельно
+		_, err = env.client.ListIncidents(withKey("writer"), &redgov1.ListIncidentsRequest{})
+		wantCode(t, err, codes.OK)
+	})
+
+	t.Run("stream counts every message", func(t *testing.T) {
+		stream, err := env.client.StreamLocation(withKey("checker"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			userID  int64
+			limited bool
+		}{{7, false}, {7, false}, {7, true}, {8, false}}
+		for i, tt := range tests {
+			if err := stream.Send(&redgov1.CheckLocationRequest{UserId: tt.userID}); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("message %d: stream ended: %v", i, err)
+			}
+			checkErr := resp.GetError()
+			if got := checkErr.GetCode() == domain.CodeRateLimited; got != tt.limited {
+				t.Errorf("message %d: error = %v, want rate limited %v", i, checkErr, tt.limited)
+			}
+			if tt.limited && checkErr.GetRetryAfterSeconds() <= 0 {
+				t.Errorf("message %d: retry_after_seconds = %d", i, checkErr.GetRetryAfterSeconds())
+			}
+		}
+	})
+}
+
+func TestIncidentCRUD(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := withKey("writer")
+
+	created, err := env.client.CreateIncident(ctx, &redgov1.CreateIncidentRequest{Description: "fire", X: 1, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Id == 0 || created.Status != string(domain.StatusActive) || created.MapId != domain.DefaultMapID {
+		t.Fatalf("created = %+v, want id, active status and default map", created)
+	}
+
+	_, err = env.client.CreateIncident(ctx, &redgov1.CreateIncidentRequest{Description: "bad", Status: "closed"})
+	wantCode(t, err, codes.InvalidArgument)
+
+	got, err := env.client.GetIncident(ctx, &redgov1.GetIncidentRequest{Id: created.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Description != "fire" || got.X != 1 || got.Y != 2 {
+		t.Errorf("got = %+v", got)
+	}
+
+	list, err := env.client.ListIncidents(ctx, &redgov1.ListIncidentsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Incidents) != 1 {
+		t.Errorf("listed %d incidents, want 1", len(list.Incidents))
+	}
+
+	desc := "smoke"
+	updated, err := env.client.UpdateIncident(ctx, &redgov1.UpdateIncidentRequest{Id: created.Id, Description: &desc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Description != "smoke" || updated.Version != 2 {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	_, err = env.client.UpdateIncident(ctx, &redgov1.UpdateIncidentRequest{Id: created.Id})
+	wantCode(t, err, codes.InvalidArgument)
+
+	if _, err := env.client.DeleteIncident(ctx, &redgov1.DeleteIncidentRequest{Id: created.Id}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = env.client.GetIncident(ctx, &redgov1.GetIncidentRequest{Id: created.Id})
+	wantCode(t, err, codes.NotFound)
+}
+
+func TestIncidentVersionCheck(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := withKey("writer")
+
+	created, err := env.client.CreateIncident(ctx, &redgov1.CreateIncidentRequest{Description: "fire", X: 1, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := "smoke"
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"update stale version", func() error {
+			_, err := env.client.UpdateIncident(ctx, &redgov1.UpdateIncidentRequest{Id: created.Id, Description: &desc, Version: created.Version + 1})
+			return err
+		}, codes.FailedPrecondition},
+		{"update negative version", func() error {
+			_, err := env.client.UpdateIncident(ctx, &redgov1.UpdateIncidentRequest{Id: created.Id, Description: &desc, Version: -1})
+			return err
+		}, codes.InvalidArgument},
+		{"update current version", func() error {
+			_, err := env.client.UpdateIncident(ctx, &redgov1.UpdateIncidentRequest{Id: created.Id, Description: &desc, Version: created.Version})
+			return err
+		}, codes.OK},
+		// После обновления версия выросла, прочитанная при создании устарела
+		{"delete stale version", func() error {
+			_, err := env.client.DeleteIncident(ctx, &redgov1.DeleteIncidentRequest{Id: created.Id, Version: created.Version})
+			return err
+		}, codes.FailedPrecondition},
+		{"delete current version", func() error {
+			_, err := env.client.DeleteIncident(ctx, &redgov1.DeleteIncidentRequest{Id: created.Id, Version: created.Version + 1})
+			return err
+		}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantCode(t, tt.call(), tt.want)
+		})
+	}
+}
+
+func TestCheckLocationUnary(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.client.CreateIncident(withKey("writer"), &redgov1.CreateIncidentRequest{Description: "fire", X: 0, Y: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := withKey("checker")
+	resp, err := env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 7, X: 3, Y: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.UserId != 7 || len(resp.Incidents) != 1 || resp.Incidents[0].Incident.Description != "fire" {
+		t.Errorf("resp = %+v, want the incident nearby", resp)
+	}
+
+	resp, err = env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{UserId: 7, X: 100, Y: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Incidents) != 0 {
+		t.Errorf("far away: got %d incidents", len(resp.Incidents))
+	}
+
+	_, err = env.client.CheckLocation(ctx, &redgov1.CheckLocationRequest{X: 1})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestStreamLocation(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.client.CreateIncident(withKey("writer"), &redgov1.CreateIncidentRequest{Description: "fire", X: 0, Y: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := env.client.StreamLocation(withKey("checker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	positions := []struct {
+		x, y float64
+		want int
+	}{{1, 1, 1}, {50, 50, 0}, {-2, 3, 1}}
+	for i, p := range positions {
+		if err := stream.Send(&redgov1.CheckLocationRequest{UserId: int64(i + 1), X: p.x, Y: p.y}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.UserId != int64(i+1) || len(resp.Incidents) != p.want {
+			t.Errorf("position %d: resp = %+v, want %d incidents", i, resp, p.want)
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("after CloseSend: %v, want EOF", err)
+	}
+}
+
+func TestStreamLocationErrorsKeepStream(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.client.CreateIncident(withKey("writer"), &redgov1.CreateIncidentRequest{Description: "fire", X: 0, Y: 0}); err != nil {
+		t.Fatal(err)
+	}
+	env.incidents.degraded = true
+
+	stream, err := env.client.StreamLocation(withKey("checker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		req       *redgov1.CheckLocationRequest
+		wantCode  string
+		wantFound int
+	}{
+		{"no user_id", &redgov1.CheckLocationRequest{X: 1, Y: 1}, domain.CodeValidation, 0},
+		{"negative limit", &redgov1.CheckLocationRequest{UserId: 2, Limit: -1}, domain.CodeValidation, 0},
+		{"valid after errors", &redgov1.CheckLocationRequest{UserId: 3, X: 1, Y: 1}, "", 1},
+	}
+
+	for _, tt := range tests {
+		if err := stream.Send(tt.req); err != nil {
+			t.Fatalf("%s: send: %v", tt.name, err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("%s: stream ended: %v", tt.name, err)
+		}
+		if resp.UserId != tt.req.UserId || resp.GetError().GetCode() != tt.wantCode || len(resp.Incidents) != tt.wantFound {
+			t.Errorf("%s: resp = %+v, want error %q and %d incidents", tt.name, resp, tt.wantCode, tt.wantFound)
+		}
+		if tt.wantCode == "" && !resp.Degraded {
+			t.Errorf("%s: degraded flag lost", tt.name)
+		}
+	}
+}
+
+func TestStreamLocationRequiresScope(t *testing.T) {
+	env := newTestEnv(t)
+
+	stream, err := env.client.StreamLocation(withKey("reader"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	wantCode(t, err, codes.PermissionDenied)
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{domain.NewValidationError(domain.CodeValidation, "bad"), codes.InvalidArgument},
+		{domain.NewUnauthorizedError("who"), codes.Unauthenticated},
+		{domain.NewForbiddenError("no"), codes.PermissionDenied},
+		{domain.ErrIncidentNotFound, codes.NotFound},
+		{domain.NewConflictError(domain.CodeConflict, "exists", nil), codes.AlreadyExists},
+		{domain.NewUnavailableError("down", errors.New("dial tcp")), codes.Unavailable},
+		{&domain.Error{Kind: domain.ErrPreconditionFailed, Code: domain.CodeVersionMismatch, Message: "stale"}, codes.FailedPrecondition},
+		{domain.NewRateLimitedError("slow down", time.Second), codes.ResourceExhausted},
+		{context.Canceled, codes.Canceled},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{errors.New("boom"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want.String(), func(t *testing.T) {
+			if got := status.Code(toStatus(tt.err)); got != tt.want {
+				t.Errorf("toStatus(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStatusHidesCause(t *testing.T) {
+	st := status.Convert(toStatus(domain.NewUnavailableError("database unavailable", errors.New("password=secret"))))
+	if want := domain.CodeUnavailable + ": database unavailable"; st.Message() != want {
+		t.Errorf("message = %q, want %q", st.Message(), want)
+	}
+
+	st = status.Convert(toStatus(errors.New("pq: relation does not exist")))
+	if st.Message() != "internal server error" {
+		t.Errorf("internal message = %q", st.Message())
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// createAPIKeyResponse — единственный ответ, в котором виден сам ключ
+type createAPIKeyResponse struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+// POST /api/v1/admin/keys
+func (h *Handler) createAPIKey(c *gin.Context) {
+	var input domain.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	key, raw, err := h.services.APIKeyService.CreateKey(c.Request.Context(), input)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createAPIKeyResponse{APIKey: *key, Key: raw})
+}
+
+// GET /api/v1/admin/keys
+func (h *Handler) listAPIKeys(c *gin.Context) {
+	keys, err := h.services.APIKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// DELETE /api/v1/admin/keys/:id — отзыв ключа, запись остается для истории
+func (h *Handler) revokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, invalidIDError())
+		return
+	}
+
+	if err := h.services.APIKeyService.RevokeKey(c.Request.Context(), id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/v1/admin/keys/:id/usage?days=7
+// Число запросов ключа по дням и маршрутам
+func (h *Handler) apiKeyUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, invalidIDError())
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+
+	usage, err := h.services.APIKeyService.KeyUsage(c.Request.Context(), id, days)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "usage": usage})
+}
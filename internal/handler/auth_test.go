@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRequireScope(t *testing.T) {
+	ts := newTestServer(t, nil)
+	withIncident(ts)
+	position := map[string]any{"user_id": 1, "x": 1, "y": 1}
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"no key", "", http.MethodGet, "/api/v1/incidents/1", nil, http.StatusUnauthorized},
+		{"unknown key", "nope", http.MethodGet, "/api/v1/incidents/1", nil, http.StatusUnauthorized},
+		{"reader reads", "reader", http.MethodGet, "/api/v1/incidents/1", nil, http.StatusOK},
+		{"reader cannot write", "reader", http.MethodPatch, "/api/v1/incidents/1", `{"status": "resolved"}`, http.StatusForbidden},
+		{"reader cannot check", "reader", http.MethodPost, "/api/v1/location/check", position, http.StatusForbidden},
+		{"checker checks", "checker", http.MethodPost, "/api/v1/location/check", position, http.StatusOK},
+		{"checker cannot read incidents", "checker", http.MethodGet, "/api/v1/incidents/1", nil, http.StatusForbidden},
+		{"writer is not admin", testAPIKey, http.MethodGet, "/api/v1/admin/keys", nil, http.StatusForbidden},
+		// Метрики и пробы открыты без ключа
+		{"metrics without key", "", http.MethodGet, "/metrics", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := ts.do(tt.method, tt.path, tt.body, "X-API-KEY", tt.key)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// problem — тело ошибки по RFC 7807. Code стабилен, Detail может меняться
+type problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+	// RequestID — тот же X-Request-ID, что в логах: по нему ошибку находят на сервере
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func init() {
+	// В ошибках валидации поля называем так же, как в JSON, а не как в Go-структуре
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+			name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// statusFor — единственное место, где вид ошибки превращается в HTTP статус
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// newProblem собирает тело ответа. Внутренние ошибки наружу не отдаются, только в лог
+func newProblem(c *gin.Context, err error) problem {
+	status := statusFor(err)
+	p := problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  c.Request.URL.Path,
+		Code:      domain.CodeInternal,
+		RequestID: logging.RequestIDFrom(c.Request.Context()),
+	}
+
+	var derr *domain.Error
+	if errors.As(err, &derr) {
+		p.Code = derr.Code
+		p.Detail = derr.Message
+		p.Errors = derr.Fields
+	}
+
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed", "method", c.Request.Method, "path", c.Request.URL.Path, logging.Err(err))
+		p.Code = domain.CodeInternal
+		p.Detail = "internal server error"
+		p.Errors = nil
+	} else if status == http.StatusServiceUnavailable {
+		slog.WarnContext(c.Request.Context(), "dependency unavailable", "method", c.Request.Method, "path", c.Request.URL.Path, logging.Err(err))
+	}
+
+	return p
+}
+
+// abortWithError отвечает problem+json и прерывает цепочку обработчиков
+func abortWithError(c *gin.Context, err error) {
+	p := newProblem(c, err)
+
+	var derr *domain.Error
+	if errors.As(err, &derr) && derr.RetryAfter > 0 {
+		// Округляем вверх: Retry-After: 0 клиенты поймут как «прямо сейчас»
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(derr.RetryAfter.Seconds()))))
+	}
+
+	c.Header("Content-Type", mimeProblemJSON)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// bindError превращает ошибку ShouldBindJSON в ошибку валидации с деталями по полям
+func bindError(err error) error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]domain.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, domain.FieldError{
+				Field:   fieldPath(fe),
+				Message: fieldMessage(fe),
+			})
+		}
+		return domain.NewValidationError(domain.CodeValidation, "request validation failed", fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return domain.NewValidationError(domain.CodeInvalidBody, "invalid request body",
+			domain.FieldError{Field: typeErr.Field, Message: "must be " + typeErr.Type.String()})
+	}
+
+	return domain.NewValidationError(domain.CodeInvalidBody, "invalid request body: "+err.Error())
+}
+
+// fieldPath убирает имя корневой структуры: "Incident.status" -> "status".
+// У анонимных структур имени нет, и путь уже начинается с JSON-поля ("checks[0].x")
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	root, rest, ok := strings.Cut(ns, ".")
+	if ok && (root == "" || unicode.IsUpper(rune(root[0]))) {
+		return rest
+	}
+	return ns
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	default:
+		return fmt.Sprintf("failed on '%s' rule", fe.Tag())
+	}
+}
+
+// invalidIDError — :id в пути не число
+func invalidIDError() error {
+	return domain.NewValidationError(domain.CodeInvalidID, "invalid id",
+		domain.FieldError{Field: "id", Message: "must be an integer"})
+}
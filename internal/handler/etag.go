@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// etag — сильный ETag из версии инцидента
+func etag(inc *domain.Incident) string {
+	return `"` + strconv.Itoa(inc.Version) + `"`
+}
+
+// ifMatchVersion достает версию из If-Match. 0 — заголовка нет или "*", проверка не нужна.
+// If-Match сравнивает сильно (RFC 7232): слабый W/"3" не совпадает ни с одной версией, как и
+// нечитаемое значение, поэтому сразу 412
+func ifMatchVersion(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return 0, domain.ErrVersionMismatch
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 {
+		return 0, domain.ErrVersionMismatch
+	}
+
+	return version, nil
+}
+
+// notModified — If-None-Match совпал с текущей версией. Здесь сравнение слабое, W/ допустим
+func notModified(c *gin.Context, inc *domain.Incident) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	current := etag(inc)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// withIncident кладет в фейк инцидент 1 версии 3
+func withIncident(ts *testServer) {
+	x, y := 1.0, 2.0
+	ts.incidents.incidents[1] = &domain.Incident{
+		ID: 1, Description: "fire", X: &x, Y: &y, Status: domain.StatusActive, MapID: domain.DefaultMapID, Version: 3,
+	}
+}
+
+func TestGetIncidentETag(t *testing.T) {
+	ts := newTestServer(t, nil)
+	withIncident(ts)
+
+	rec := ts.do(http.MethodGet, "/api/v1/incidents/1", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("status %d, ETag %q; want 200 and \"3\"", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{`"3"`, http.StatusNotModified},
+		// If-None-Match сравнивает слабо
+		{`W/"3"`, http.StatusNotModified},
+		{`"1", "3"`, http.StatusNotModified},
+		{`*`, http.StatusNotModified},
+		{`"2"`, http.StatusOK},
+		{`3`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ifNoneMatch, func(t *testing.T) {
+			rec := ts.do(http.MethodGet, "/api/v1/incidents/1", nil, "If-None-Match", tt.ifNoneMatch)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Header().Get("ETag") != `"3"` {
+				t.Errorf("ETag %q, want \"3\"", rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"absent", "", http.StatusOK},
+		{"any", "*", http.StatusOK},
+		{"current", `"3"`, http.StatusOK},
+		{"stale", `"2"`, http.StatusPreconditionFailed},
+		// If-Match сравнивает сильно: слабый тег не совпадает даже с текущей версией
+		{"weak current", `W/"3"`, http.StatusPreconditionFailed},
+		{"weak stale", `W/"2"`, http.StatusPreconditionFailed},
+		{"garbage", `"abc"`, http.StatusPreconditionFailed},
+		{"zero", `"0"`, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, method := range []string{http.MethodPut, http.MethodPatch} {
+				ts := newTestServer(t, nil)
+				withIncident(ts)
+
+				var headers []string
+				if tt.ifMatch != "" {
+					headers = []string{"If-Match", tt.ifMatch}
+				}
+				rec := ts.do(method, "/api/v1/incidents/1", map[string]any{"description": "smoke"}, headers...)
+
+				want := tt.want
+				if method == http.MethodPut && want == http.StatusOK {
+					want = http.StatusNoContent
+				}
+				if rec.Code != want {
+					t.Fatalf("%s: status %d, want %d: %s", method, rec.Code, want, rec.Body)
+				}
+				if want == http.StatusPreconditionFailed {
+					if ts.incidents.incidents[1].Version != 3 {
+						t.Errorf("%s: incident changed despite 412", method)
+					}
+					continue
+				}
+				if got := rec.Header().Get("ETag"); got != `"4"` {
+					t.Errorf("%s: ETag %q, want \"4\"", method, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeat — как часто слать комментарий, чтобы прокси не рвали тихое соединение
+const sseHeartbeat = 15 * time.Second
+
+// GET /api/v1/incidents/events
+// Server-Sent Events с изменениями инцидентов. Фильтры: ?status=, ?map_id=.
+// Переподключение продолжает с заголовка Last-Event-ID (или ?last_event_id=)
+func (h *Handler) incidentEvents(c *gin.Context) {
+	filter := domain.IncidentEventFilter{
+		Status: domain.IncidentStatus(c.Query("status")),
+		MapID:  c.Query("map_id"),
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+
+	ctx := c.Request.Context()
+
+	// Подписываемся до replay, чтобы не потерять события между чтением истории и подпиской
+	live, err := h.services.IncidentService.SubscribeEvents(ctx)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var backlog []domain.IncidentEvent
+	if lastID != "" {
+		backlog, err = h.services.IncidentService.EventsSince(ctx, lastID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
+	// Поток живет дольше WriteTimeout сервера, снимаем дедлайн только для этого запроса
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// cutoff — последнее событие, которое клиент уже получил: Last-Event-ID или конец replay.
+	// Все, что не новее, уже было в истории; дальше граница не двигается: pub/sub с нескольких реплик
+	// может доставить события не в порядке ID, и сдвиг по живым событиям терял бы отставшие
+	cutoff := lastID
+	for _, ev := range backlog {
+		if filter.Match(ev) {
+			writeEvent(c, ev)
+		}
+		cutoff = ev.ID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		case ev, ok := <-live:
+			if !ok {
+				return
+			}
+			// Событие уже ушло клиенту при replay
+			if cutoff != "" && !eventIDAfter(ev.ID, cutoff) {
+				continue
+			}
+
+			if filter.Match(ev) {
+				writeEvent(c, ev)
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, ev domain.IncidentEvent) {
+	_ = sse.Encode(c.Writer, sse.Event{
+		Id:    ev.ID,
+		Event: string(ev.Type),
+		Data:  ev,
+	})
+}
+
+// eventIDAfter сравнивает ID Redis Stream вида "ms-seq"
+func eventIDAfter(a, b string) bool {
+	aMs, aSeq := splitEventID(a)
+	bMs, bSeq := splitEventID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+func splitEventID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
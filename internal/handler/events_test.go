@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+func events(ids ...string) []domain.IncidentEvent {
+	out := make([]domain.IncidentEvent, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, domain.IncidentEvent{ID: id, Type: domain.EventIncidentUpdated})
+	}
+	return out
+}
+
+// sentEventIDs — id: из SSE-ответа по порядку
+func sentEventIDs(body string) []string {
+	var ids []string
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		if id, ok := strings.CutPrefix(sc.Text(), "id:"); ok {
+			ids = append(ids, strings.TrimSpace(id))
+		}
+	}
+	return ids
+}
+
+func TestIncidentEventsReplayAndLive(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastEventID string
+		history     []string
+		live        []string
+		want        []string
+	}{
+		{
+			name: "no replay",
+			live: []string{"5-0", "4-0"},
+			want: []string{"5-0", "4-0"},
+		},
+		{
+			// Живые события, которые уже были в replay, отбрасываются
+			name:        "dedupe against replay",
+			lastEventID: "1-0",
+			history:     []string{"1-0", "2-0", "3-0"},
+			live:        []string{"2-0", "3-0", "4-0"},
+			want:        []string{"2-0", "3-0", "4-0"},
+		},
+		{
+			// Реплики публикуют не в порядке ID: отставшее событие новее конца replay и должно дойти
+			name:        "out of order live events",
+			lastEventID: "1-0",
+			history:     []string{"1-0", "2-0"},
+			live:        []string{"2-0", "5-0", "4-0", "3-0"},
+			want:        []string{"2-0", "5-0", "4-0", "3-0"},
+		},
+		{
+			name:        "empty replay keeps client cutoff",
+			lastEventID: "3-0",
+			history:     []string{"1-0", "2-0", "3-0"},
+			live:        []string{"3-0", "5-0", "4-0"},
+			want:        []string{"5-0", "4-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, nil)
+			ts.incidents.history = events(tt.history...)
+			ts.incidents.live = events(tt.live...)
+
+			var headers []string
+			if tt.lastEventID != "" {
+				headers = []string{"Last-Event-ID", tt.lastEventID}
+			}
+			rec := ts.do(http.MethodGet, "/api/v1/incidents/events", nil, headers...)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			if got := sentEventIDs(rec.Body.String()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sent %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
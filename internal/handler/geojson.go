@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mimeGeoJSON = "application/geo+json"
+
+// wantsGeoJSON — клиент просит GeoJSON либо параметром ?format=geojson,
+// либо заголовком Accept: application/geo+json
+func wantsGeoJSON(c *gin.Context) bool {
+	if strings.EqualFold(c.Query("format"), "geojson") {
+		return true
+	}
+	return strings.Contains(c.GetHeader("Accept"), mimeGeoJSON)
+}
+
+// renderGeoJSON отдает документ с правильным Content-Type.
+// gin не перезаписывает заголовок, если он уже выставлен
+func renderGeoJSON(c *gin.Context, code int, obj interface{}) {
+	c.Header("Content-Type", mimeGeoJSON+"; charset=utf-8")
+	c.JSON(code, obj)
+}
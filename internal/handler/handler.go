@@ -1,42 +1,141 @@
 package handler
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
 	"github.com/ArtemChadaev/RedGo/internal/service"
 	"github.com/ArtemChadaev/RedGo/internal/worker"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Handler struct {
 	services *service.Service
 	worker   *worker.WebhookWorker
+
+	ready atomic.Bool // false — идет остановка, /readyz отвечает 503
+
+	// WebSocket-стримы. http.Server.Shutdown не ждет захваченные соединения, их закрывает CloseStreams
+	streamsMu     sync.Mutex
+	streams       sync.WaitGroup
+	streamsClosed bool
+	stopStreams   chan struct{}
 }
 
 func NewHandler(services *service.Service, worker *worker.WebhookWorker) *Handler {
-	return &Handler{
-		services: services,
-		worker:   worker,
+	h := &Handler{
+		services:    services,
+		worker:      worker,
+		stopStreams: make(chan struct{}),
 	}
+	h.ready.Store(true)
+	return h
 }
 
-func (h *Handler) Routes(apiKey string) *gin.Engine {
+// CloseStreams закрывает открытые WebSocket-стримы, отказывает новым и ждет, пока их обработчики выйдут.
+// После него стримы больше не сохраняют проверки — можно дописывать буфер
+func (h *Handler) CloseStreams(ctx context.Context) error {
+	h.streamsMu.Lock()
+	if !h.streamsClosed {
+		h.streamsClosed = true
+		close(h.stopStreams)
+	}
+	h.streamsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackStream учитывает новый стрим. false — идет остановка, стрим открывать нельзя
+func (h *Handler) trackStream() bool {
+	h.streamsMu.Lock()
+	defer h.streamsMu.Unlock()
+	if h.streamsClosed {
+		return false
+	}
+	h.streams.Add(1)
+	return true
+}
+
+func (h *Handler) Routes() *gin.Engine {
 	router := gin.New()
+	// requestID первым — его видят все остальные; recovery последним — упавший запрос попадет в лог и метрики как 500.
+	// Скрейп метрик и пробы идут каждые несколько секунд, спаны на них только шумят
+	router.Use(
+		requestID(),
+		otelgin.Middleware("redgo", otelgin.WithGinFilter(func(c *gin.Context) bool { return !isProbe(c) })),
+		recordMetrics(),
+		requestLogger(),
+		recovery(),
+	)
+
+	read := h.requireScope(domain.ScopeIncidentsRead)
+	write := h.requireScope(domain.ScopeIncidentsWrite)
+	check := h.requireScope(domain.ScopeLocationCheck)
+	admin := h.requireScope(domain.ScopeAdmin)
+	limit := h.tenantRateLimit()
+	rate := h.rateLimit()
+
+	// Без авторизации: Prometheus скрейпит из внутренней сети, наружу /metrics не публикуем
+	router.GET("/metrics", h.metricsHandler())
+
+	// Пробы Kubernetes тоже без ключа: kubelet его не передаст
+	router.GET("/livez", h.livez)
+	router.GET("/readyz", h.readyz)
+	router.GET("/health", admin, h.healthCheck)
 
 	api := router.Group("/api/v1")
 	{
-		// Группа с защитой API-ключом
-		incident := api.Group("/incidents", h.apiKeyMiddleware(apiKey))
+		incident := api.Group("/incidents")
+		{
+			incident.POST("/", write, h.createIncident)
+			incident.GET("/", read, h.getIncidents)
+			incident.GET("/:id", read, h.getIncidentByID)
+			incident.PUT("/:id", write, h.updateIncident)
+			incident.PATCH("/:id", write, h.patchIncident)
+			incident.DELETE("/:id", write, h.deleteIncident)
+			incident.POST("/:id/restore", write, h.restoreIncident)
+			incident.GET("/:id/audit", read, h.getIncidentAudit)
+			incident.GET("/stats", read, h.getStats)
+			incident.GET("/events", read, h.incidentEvents)
+		}
+
+		adminGroup := api.Group("/admin", admin)
 		{
-			incident.POST("/", h.createIncident)
-			incident.GET("/", h.getIncidents)
-			incident.GET("/:id", h.getIncidentByID)
-			incident.PUT("/:id", h.updateIncident)
-			incident.DELETE("/:id", h.deleteIncident)
-			incident.GET("/stats", h.getStats)
+			adminGroup.DELETE("/incidents/:id", h.purgeIncident)
+
+			adminGroup.POST("/keys", h.createAPIKey)
+			adminGroup.GET("/keys", h.listAPIKeys)
+			adminGroup.DELETE("/keys/:id", h.revokeAPIKey)
+			adminGroup.GET("/keys/:id/usage", h.apiKeyUsage)
+
+			// Тенанты — только для admin тенанта хостинга
+			adminGroup.POST("/tenants", h.createTenant)
+			adminGroup.GET("/tenants", h.listTenants)
+			adminGroup.PATCH("/tenants/:id", h.updateTenant)
 		}
 
-		api.POST("/location/check", h.checkLocation)
-		api.GET("/system/health", h.healthCheck)
+		api.POST("/location/check", check, limit, rate, h.checkLocation)
+		api.POST("/location/check/batch", check, rate, h.checkLocationBatch)
+		api.GET("/location/stream", check, limit, rate, h.streamLocation)
+		api.GET("/system/health", admin, h.healthCheck)
+
+		api.GET("/openapi.json", h.openAPISpec)
+		api.GET("/docs", h.apiDocs)
 	}
 
 	return router
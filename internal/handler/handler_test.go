@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const testAPIKey = "test-key"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeIncidents запоминает проверки и отдает инциденты из памяти
+type fakeIncidents struct {
+	domain.IncidentService
+
+	mu        sync.Mutex
+	checks    []domain.LocationCheck
+	incidents map[int]*domain.Incident
+
+	// history — поток событий для replay, live — что придет подписчику, после них канал закрывается
+	history []domain.IncidentEvent
+	live    []domain.IncidentEvent
+}
+
+func (f *fakeIncidents) SubscribeEvents(context.Context) (<-chan domain.IncidentEvent, error) {
+	ch := make(chan domain.IncidentEvent, len(f.live))
+	for _, ev := range f.live {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeIncidents) EventsSince(_ context.Context, lastID string) ([]domain.IncidentEvent, error) {
+	var out []domain.IncidentEvent
+	for _, ev := range f.history {
+		if eventIDAfter(ev.ID, lastID) {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeIncidents) CheckLocation(_ context.Context, check domain.LocationCheck, _ domain.CheckOptions) (*domain.LocationCheckResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checks = append(f.checks, check)
+	return &domain.LocationCheckResult{Incidents: []domain.NearbyIncident{}}, nil
+}
+
+func (f *fakeIncidents) CheckLocations(_ context.Context, checks []domain.LocationCheck, _ domain.CheckOptions) (map[int]*domain.LocationCheckResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	results := make(map[int]*domain.LocationCheckResult, len(checks))
+	for _, check := range checks {
+		f.checks = append(f.checks, check)
+		results[check.UserID] = &domain.LocationCheckResult{Incidents: []domain.NearbyIncident{}}
+	}
+	return results, nil
+}
+
+func (f *fakeIncidents) GetIncidentByID(_ context.Context, id int) (*domain.Incident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inc, ok := f.incidents[id]
+	if !ok {
+		return nil, domain.ErrIncidentNotFound
+	}
+	cp := *inc
+	return &cp, nil
+}
+
+// Update проверяет версию, как репозиторий: 0 — без проверки
+func (f *fakeIncidents) Update(_ context.Context, id int, input domain.UpdateIncidentInput, version int) (*domain.Incident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inc, ok := f.incidents[id]
+	if !ok {
+		return nil, domain.ErrIncidentNotFound
+	}
+	if version != 0 && version != inc.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+	if input.X != nil {
+		inc.X = input.X
+	}
+	if input.Y != nil {
+		inc.Y = input.Y
+	}
+	if input.Description != nil {
+		inc.Description = *input.Description
+	}
+	if input.Status != nil {
+		inc.Status = *input.Status
+	}
+	if input.MapID != nil {
+		inc.MapID = *input.MapID
+	}
+	inc.Version++
+	cp := *inc
+	return &cp, nil
+}
+
+func (f *fakeIncidents) savedChecks() []domain.LocationCheck {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.LocationCheck(nil), f.checks...)
+}
+
+// Ключи тестов тенанта default и их права. testAPIKey — все, кроме admin
+var testKeys = map[string][]domain.Scope{
+	testAPIKey: {domain.ScopeIncidentsRead, domain.ScopeIncidentsWrite, domain.ScopeLocationCheck},
+	"reader":   {domain.ScopeIncidentsRead},
+	"checker":  {domain.ScopeLocationCheck},
+}
+
+type fakeKeys struct {
+	domain.APIKeyService
+}
+
+func (fakeKeys) Authenticate(_ context.Context, rawKey string) (*domain.Principal, error) {
+	scopes, ok := testKeys[rawKey]
+	if !ok {
+		return nil, domain.NewUnauthorizedError("invalid api key")
+	}
+	return &domain.Principal{
+		ID:       "key:" + rawKey,
+		Name:     rawKey,
+		Kind:     domain.PrincipalAPIKey,
+		TenantID: domain.DefaultTenantID,
+		Scopes:   scopes,
+	}, nil
+}
+
+func (fakeKeys) RecordUsage(context.Context, *domain.Principal, string) {}
+
+// fakeTenants — тенант с лимитом limit проверок, 0 — без лимита
+type fakeTenants struct {
+	domain.TenantService
+
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+func (f *fakeTenants) GetTenant(_ context.Context, id string) (*domain.Tenant, error) {
+	return &domain.Tenant{ID: id, Name: id}, nil
+}
+
+func (f *fakeTenants) CheckRateLimit(_ context.Context, checks int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.used += checks
+	if f.limit > 0 && f.used > f.limit {
+		return domain.NewRateLimitedError("tenant rate limit exceeded", time.Minute)
+	}
+	return nil
+}
+
+// memRateLimits — RateLimitRepository без Redis: окно не скользит, для тестов хватает счетчика
+type memRateLimits struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (m *memRateLimits) Allow(_ context.Context, key string, limit int, window time.Duration, cost int) (domain.RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+	if m.counts[key]+cost > limit {
+		return domain.RateLimitResult{Limit: limit, RetryAfter: window, Reset: window}, nil
+	}
+	m.counts[key] += cost
+	return domain.RateLimitResult{Allowed: true, Limit: limit, Remaining: limit - m.counts[key], Reset: window}, nil
+}
+
+type testServer struct {
+	incidents *fakeIncidents
+	tenants   *fakeTenants
+	handler   *Handler
+	router    *gin.Engine
+}
+
+func newTestServer(t *testing.T, rules []domain.RateLimitRule) *testServer {
+	t.Helper()
+
+	ts := &testServer{
+		incidents: &fakeIncidents{incidents: make(map[int]*domain.Incident)},
+		tenants:   &fakeTenants{},
+	}
+	services := &service.Service{
+		IncidentService:  ts.incidents,
+		APIKeyService:    fakeKeys{},
+		TenantService:    ts.tenants,
+		RateLimitService: service.NewRateLimitService(&memRateLimits{}, rules),
+	}
+	ts.handler = NewHandler(services, nil)
+	ts.router = ts.handler.Routes()
+	return ts
+}
+
+func (ts *testServer) do(method, path string, body any, headers ...string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		if raw, ok := body.(string); ok {
+			buf.WriteString(raw)
+		} else {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", testAPIKey)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	ts.router.ServeHTTP(rec, req)
+	return rec
+}
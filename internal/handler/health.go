@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthOK           = "ok"
+	healthDegraded     = "degraded"      // одна из зависимостей лежит, проверки отвечают из кэша или памяти
+	healthUnhealthy    = "unhealthy"     // лежат обе, отвечать нечем
+	healthShuttingDown = "shutting_down" // идет graceful shutdown
+)
+
+// SetReady переключает /readyz. main снимает готовность перед остановкой сервера,
+// чтобы балансировщик успел убрать под из ротации
+func (h *Handler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// dependencies пингует Postgres и Redis. nil — зависимость отвечает
+func (h *Handler) dependencies(ctx context.Context) (pgErr, redisErr error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return h.services.HealthCheckDB(ctx), h.services.HealthCheckRedis(ctx)
+}
+
+// healthStatus сводит состояние к одному статусу. Одна живая зависимость — еще не повод снимать под:
+// без Postgres проверки идут из кэша, без Redis — прямо из базы
+func (h *Handler) healthStatus(pgErr, redisErr error) string {
+	switch {
+	case !h.ready.Load():
+		return healthShuttingDown
+	case pgErr != nil && redisErr != nil:
+		return healthUnhealthy
+	case pgErr != nil || redisErr != nil:
+		return healthDegraded
+	default:
+		return healthOK
+	}
+}
+
+func healthHTTPStatus(status string) int {
+	if status == healthUnhealthy || status == healthShuttingDown {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func upDown(err error) string {
+	if err != nil {
+		return "down"
+	}
+	return "up"
+}
+
+// GET /livez — процесс жив и обслуживает HTTP. Зависимости не проверяем:
+// рестарт пода не поднимет упавший Postgres
+func (h *Handler) livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": healthOK})
+}
+
+// GET /readyz — можно ли слать трафик. Без авторизации и без текстов ошибок, их видно в /health
+func (h *Handler) readyz(c *gin.Context) {
+	pgErr, redisErr := h.dependencies(c.Request.Context())
+	status := h.healthStatus(pgErr, redisErr)
+
+	c.JSON(healthHTTPStatus(status), gin.H{
+		"status":   status,
+		"postgres": upDown(pgErr),
+		"redis":    upDown(redisErr),
+	})
+}
+
+// GET /health, GET /api/v1/system/health — подробное состояние для оператора
+func (h *Handler) healthCheck(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	pgErr, redisErr := h.dependencies(ctx)
+	status := h.healthStatus(pgErr, redisErr)
+	details := make(map[string]interface{})
+
+	details["postgres"] = upDown(pgErr)
+	if pgErr != nil {
+		details["postgres"] = "down: " + pgErr.Error()
+	}
+	details["redis"] = upDown(redisErr)
+	if redisErr != nil {
+		details["redis"] = "down: " + redisErr.Error()
+	}
+
+	// Отказы лимитера по "маршрут by" с момента старта
+	details["rate_limit_rejections"] = h.services.RateLimitService.Rejections()
+
+	workerStats, err := h.worker.GetStats(ctx)
+	if err != nil {
+		details["worker_stats"] = "error: " + err.Error()
+	} else {
+		details["worker_stats"] = workerStats
+	}
+
+	c.JSON(healthHTTPStatus(status), gin.H{
+		"status":    status,
+		"details":   details,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -1,10 +1,8 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/ArtemChadaev/RedGo/internal/domain"
 	"github.com/gin-gonic/gin"
@@ -16,9 +14,7 @@ func (h *Handler) createIncident(c *gin.Context) {
 
 	// 1. Валидация JSON (x, y и корректность статуса, если он передан)
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid input: x, y are required; status must be 'active' or 'inactive'",
-		})
+		abortWithError(c, bindError(err))
 		return
 	}
 
@@ -29,7 +25,7 @@ func (h *Handler) createIncident(c *gin.Context) {
 
 	// 3. Сохранение в базу через сервис
 	if err := h.services.IncidentService.CreateIncident(c.Request.Context(), &input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
@@ -41,9 +37,24 @@ func (h *Handler) getIncidents(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "0"))
 
-	incidents, err := h.services.IncidentService.GetIncidents(c.Request.Context(), page, pageSize)
+	filter := domain.IncidentFilter{
+		MapID:   c.Query("map_id"),
+		Deleted: c.Query("deleted"),
+	}
+	if filter.Deleted != "" && filter.Deleted != domain.DeletedInclude && filter.Deleted != domain.DeletedOnly {
+		abortWithError(c, domain.NewValidationError(domain.CodeValidation, "invalid query parameter",
+			domain.FieldError{Field: "deleted", Message: "must be one of: include only"}))
+		return
+	}
+
+	incidents, err := h.services.IncidentService.GetIncidents(c.Request.Context(), filter, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
+		return
+	}
+
+	if wantsGeoJSON(c) {
+		renderGeoJSON(c, http.StatusOK, domain.NewFeatureCollection(incidents))
 		return
 	}
 
@@ -54,13 +65,24 @@ func (h *Handler) getIncidents(c *gin.Context) {
 func (h *Handler) getIncidentByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		abortWithError(c, invalidIDError())
 		return
 	}
 
 	inc, err := h.services.IncidentService.GetIncidentByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(inc))
+	if notModified(c, inc) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if wantsGeoJSON(c) {
+		renderGeoJSON(c, http.StatusOK, inc.ToFeature())
 		return
 	}
 
@@ -71,27 +93,36 @@ func (h *Handler) getIncidentByID(c *gin.Context) {
 func (h *Handler) updateIncident(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		abortWithError(c, invalidIDError())
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
 
 	var input domain.UpdateIncidentInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input format or status"})
+		abortWithError(c, bindError(err))
 		return
 	}
 
 	// Проверка: прислано ли хотя бы одно поле
-	if input.X == nil && input.Y == nil && input.Description == nil && input.Status == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one field (x, y, description, or status) must be provided"})
+	if input.X == nil && input.Y == nil && input.Description == nil && input.Status == nil && input.MapID == nil {
+		abortWithError(c, domain.NewValidationError(domain.CodeValidation,
+			"at least one field (x, y, description, status or map_id) must be provided"))
 		return
 	}
 
-	if err := h.services.IncidentService.Update(c.Request.Context(), id, input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	updated, err := h.services.IncidentService.Update(c.Request.Context(), id, input, version)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(updated))
 	c.Status(http.StatusNoContent)
 }
 
@@ -99,22 +130,82 @@ func (h *Handler) updateIncident(c *gin.Context) {
 func (h *Handler) deleteIncident(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		abortWithError(c, invalidIDError())
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
-	if err := h.services.IncidentService.DeleteIncident(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	if err := h.services.IncidentService.DeleteIncident(c.Request.Context(), id, version); err != nil {
+		abortWithError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// POST /api/v1/incidents/:id/restore
+func (h *Handler) restoreIncident(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, invalidIDError())
+		return
+	}
+
+	inc, err := h.services.IncidentService.RestoreIncident(c.Request.Context(), id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(inc))
+	c.JSON(http.StatusOK, inc)
+}
+
+// DELETE /api/v1/admin/incidents/:id
+// Окончательное удаление записи, в отличие от DELETE /incidents/:id
+func (h *Handler) purgeIncident(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, invalidIDError())
+		return
+	}
+
+	if err := h.services.IncidentService.PurgeIncident(c.Request.Context(), id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/v1/incidents/:id/audit?limit=100
+// Кто и когда менял инцидент, от новых записей к старым
+func (h *Handler) getIncidentAudit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, invalidIDError())
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	records, err := h.services.IncidentService.GetAudit(c.Request.Context(), id, limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
 // GET /api/v1/incidents/stats
 func (h *Handler) getStats(c *gin.Context) {
 	count, err := h.services.IncidentService.GetStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
@@ -122,68 +213,128 @@ func (h *Handler) getStats(c *gin.Context) {
 }
 
 // POST /api/v1/location/check
+// По умолчанию возвращает массив совпадений (ближайшие первыми).
+// С include_nearest=true отвечает объектом {incidents, warning}
+// X и Y указателями, как в domain.Incident: 0 — допустимая координата
 func (h *Handler) checkLocation(c *gin.Context) {
 	var input struct {
-		UserID int     `json:"user_id" binding:"required"`
-		X      float64 `json:"x" binding:"required"`
-		Y      float64 `json:"y" binding:"required"`
+		UserID         int      `json:"user_id" binding:"required"`
+		MapID          string   `json:"map_id" binding:"omitempty,max=64"`
+		X              *float64 `json:"x" binding:"required"`
+		Y              *float64 `json:"y" binding:"required"`
+		Limit          int      `json:"limit" binding:"omitempty,min=0"`
+		IncludeNearest bool     `json:"include_nearest"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	nearby, err := h.services.IncidentService.CheckLocation(c.Request.Context(), input.UserID, input.X, input.Y)
+	res, err := h.services.IncidentService.CheckLocation(c.Request.Context(), domain.LocationCheck{
+		UserID: input.UserID,
+		MapID:  input.MapID,
+		X:      *input.X,
+		Y:      *input.Y,
+	}, domain.CheckOptions{
+		Limit:          input.Limit,
+		IncludeNearest: input.IncludeNearest,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
+	markDegraded(c, res.Degraded)
 
-	c.JSON(http.StatusOK, nearby)
-}
+	if wantsGeoJSON(c) {
+		renderGeoJSON(c, http.StatusOK, domain.NewCheckFeatureCollection(res))
+		return
+	}
 
-// GET /api/v1/system/health
-func (h *Handler) healthCheck(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
-	defer cancel()
+	if input.IncludeNearest {
+		c.JSON(http.StatusOK, res)
+		return
+	}
 
-	var status = "ok"
-	details := make(map[string]interface{})
+	c.JSON(http.StatusOK, res.Incidents)
+}
 
-	// 1. Проверка PostgreSQL
-	// Предполагаем, что в репозитории есть метод Ping
-	if err := h.services.HealthCheckDB(ctx); err != nil {
-		status = "unhealthy"
-		details["postgres"] = "down: " + err.Error()
-	} else {
-		details["postgres"] = "up"
+// POST /api/v1/location/check/batch
+// Отвечает объектом {user_id: {incidents, warning}}.
+// Лимит тенанта и правила user считаются здесь, по позициям пакета: middleware видит только запрос целиком
+func (h *Handler) checkLocationBatch(c *gin.Context) {
+	var input struct {
+		Checks []struct {
+			UserID int      `json:"user_id" binding:"required"`
+			MapID  string   `json:"map_id" binding:"omitempty,max=64"`
+			X      *float64 `json:"x" binding:"required"`
+			Y      *float64 `json:"y" binding:"required"`
+		} `json:"checks" binding:"required,min=1,max=5000,dive"`
+		Limit          int  `json:"limit" binding:"omitempty,min=0"`
+		IncludeNearest bool `json:"include_nearest"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithError(c, bindError(err))
+		return
 	}
 
-	// 2. Проверка Redis
-	if err := h.services.HealthCheckRedis(ctx); err != nil {
-		status = "unhealthy"
-		details["redis"] = "down: " + err.Error()
-	} else {
-		details["redis"] = "up"
+	ctx := c.Request.Context()
+	if err := h.services.TenantService.CheckRateLimit(ctx, len(input.Checks)); err != nil {
+		abortWithError(c, err)
+		return
 	}
 
-	// 3. Сбор статистики воркеров
-	workerStats, err := h.worker.GetStats(ctx)
-	if err != nil {
-		details["worker_stats"] = "error: " + err.Error()
-	} else {
-		details["worker_stats"] = workerStats
+	// Правила user — по каждому игроку пакета, ключ и IP уже посчитал rateLimit
+	route := c.Request.Method + " " + c.FullPath()
+	seen := make(map[int]struct{}, len(input.Checks))
+	for _, in := range input.Checks {
+		if _, ok := seen[in.UserID]; ok {
+			continue
+		}
+		seen[in.UserID] = struct{}{}
+
+		userID := strconv.Itoa(in.UserID)
+		if _, err := h.services.RateLimitService.Apply(ctx, route, func(by domain.RateLimitBy) string {
+			if by == domain.RateLimitByUser {
+				return userID
+			}
+			return ""
+		}); err != nil {
+			abortWithError(c, err)
+			return
+		}
 	}
 
-	// Определяем HTTP статус
-	httpStatus := http.StatusOK
-	if status != "ok" {
-		httpStatus = http.StatusServiceUnavailable // 503
+	checks := make([]domain.LocationCheck, 0, len(input.Checks))
+	for _, in := range input.Checks {
+		checks = append(checks, domain.LocationCheck{
+			UserID: in.UserID,
+			MapID:  in.MapID,
+			X:      *in.X,
+			Y:      *in.Y,
+		})
 	}
 
-	c.JSON(httpStatus, gin.H{
-		"status":    status,
-		"details":   details,
-		"timestamp": time.Now().Format(time.RFC3339),
+	results, err := h.services.IncidentService.CheckLocations(ctx, checks, domain.CheckOptions{
+		Limit:          input.Limit,
+		IncludeNearest: input.IncludeNearest,
 	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	for _, res := range results {
+		if res.Degraded {
+			markDegraded(c, true)
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// markDegraded — ответ без Postgres виден и там, где тело — голый массив совпадений
+func markDegraded(c *gin.Context, degraded bool) {
+	if degraded {
+		c.Header("X-Degraded", "true")
+	}
 }
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// 0 — экватор и нулевой меридиан в wgs84, а не пропущенное поле
+func TestCheckLocationAcceptsZeroCoordinates(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	rec := ts.do(http.MethodPost, "/api/v1/location/check", `{"user_id": 1, "x": 0, "y": 0}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	rec = ts.do(http.MethodPost, "/api/v1/location/check/batch", `{"checks": [{"user_id": 2, "x": 0, "y": 0}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("batch status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	checks := ts.incidents.savedChecks()
+	if len(checks) != 2 {
+		t.Fatalf("saved %d checks, want 2", len(checks))
+	}
+	for _, c := range checks {
+		if c.X != 0 || c.Y != 0 {
+			t.Errorf("check %+v: want zero coordinates", c)
+		}
+	}
+}
+
+func TestCheckLocationRequiresCoordinates(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	for _, body := range []string{
+		`{"user_id": 1, "y": 0}`,
+		`{"user_id": 1, "x": 0}`,
+		`{"user_id": 1, "x": null, "y": 1}`,
+	} {
+		rec := ts.do(http.MethodPost, "/api/v1/location/check", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, rec.Code)
+		}
+	}
+	if n := len(ts.incidents.savedChecks()); n != 0 {
+		t.Errorf("saved %d checks from invalid requests", n)
+	}
+}
+
+func TestCheckLocationBatchRateLimits(t *testing.T) {
+	route := http.MethodPost + " /api/v1/location/check/batch"
+	batch := func(userIDs ...int) map[string]any {
+		checks := make([]map[string]any, 0, len(userIDs))
+		for _, id := range userIDs {
+			checks = append(checks, map[string]any{"user_id": id, "x": 1, "y": 1})
+		}
+		return map[string]any{"checks": checks}
+	}
+
+	tests := []struct {
+		name   string
+		limit  int
+		rules  []domain.RateLimitRule
+		sizes  [][]int
+		status []int
+	}{
+		{
+			name:   "tenant limit counts every position",
+			limit:  5,
+			sizes:  [][]int{{1, 2, 3}, {4, 5}, {6}},
+			status: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:   "user rule per user_id",
+			rules:  []domain.RateLimitRule{{Route: route, By: domain.RateLimitByUser, Limit: 1, Window: time.Minute}},
+			sizes:  [][]int{{1, 2}, {3}, {3, 4}},
+			status: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, tt.rules)
+			ts.tenants.limit = tt.limit
+
+			for i, ids := range tt.sizes {
+				rec := ts.do(http.MethodPost, "/api/v1/location/check/batch", batch(ids...))
+				if rec.Code != tt.status[i] {
+					t.Fatalf("batch %d: status %d, want %d: %s", i, rec.Code, tt.status[i], rec.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestID берет X-Request-ID клиента или выдает свой, кладет его в контекст и возвращает в ответе
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewID()
+		}
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// requestLogger пишет строку на каждый запрос. 5xx — error, 4xx — warn, остальное — info
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case isProbe(c):
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if p := domain.PrincipalFrom(c.Request.Context()); p != nil {
+			attrs = append(attrs, slog.String("principal", p.ID), slog.String("tenant_id", p.TenantID))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// isProbe — служебные запросы мониторинга, которые идут постоянно
+func isProbe(c *gin.Context) bool {
+	switch c.FullPath() {
+	case "/metrics", "/livez", "/readyz":
+		return true
+	}
+	return false
+}
+
+// recovery превращает панику хендлера в 500 и пишет стек, не роняя остальные запросы
+func recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "panic in handler",
+				"panic", rec, "route", c.FullPath(), "stack", string(debug.Stack()))
+
+			// Соединение уже отдано (WebSocket, SSE) — ответить нечем
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			abortWithError(c, errors.New("panic recovered"))
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	tests := []struct {
+		name string
+		sent string
+		keep bool
+	}{
+		{"client id kept", "client-req-1", true},
+		{"missing id generated", "", false},
+		{"unprintable id replaced", "bad id\twith tabs", false},
+		{"long id replaced", strings.Repeat("x", 129), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := ts.do(http.MethodGet, "/livez", nil, requestIDHeader, tt.sent)
+			got := rec.Header().Get(requestIDHeader)
+			if tt.keep && got != tt.sent {
+				t.Errorf("%s = %q, want %q", requestIDHeader, got, tt.sent)
+			}
+			if !tt.keep && (got == tt.sent || !logging.ValidRequestID(got)) {
+				t.Errorf("%s = %q, want a fresh id", requestIDHeader, got)
+			}
+		})
+	}
+}
+
+func TestRecoveryLogsRequestID(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	if err := logging.Setup(&buf, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Тот же порядок, что в Routes
+	router := gin.New()
+	router.Use(requestID(), requestLogger(), recovery())
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("panic value leaked to client: %s", rec.Body)
+	}
+
+	levels := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if entry["request_id"] != "req-42" {
+			t.Errorf("%v: request_id = %v, want req-42", entry["msg"], entry["request_id"])
+		}
+		levels[entry["msg"].(string)] = entry["level"].(string)
+	}
+	if levels["panic in handler"] != "ERROR" || levels["request"] != "ERROR" {
+		t.Errorf("log levels = %v, want panic and request at ERROR", levels)
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRouteLabels(t *testing.T) {
+	ts := newTestServer(t, nil)
+	withIncident(ts)
+
+	ts.do(http.MethodGet, "/api/v1/incidents/1", nil)
+	ts.do(http.MethodGet, "/api/v1/incidents/1", nil, "X-API-KEY", "nope")
+	ts.do(http.MethodGet, "/api/v1/incidents/999", nil)
+	ts.do(http.MethodGet, "/no/such/path", nil)
+
+	rec := ts.do(http.MethodGet, "/metrics", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	body := rec.Body.String()
+
+	// Маршрут — шаблон gin, а не путь: ID не раздувают число рядов
+	for _, want := range []string{
+		`redgo_http_requests_total{code="200",method="GET",route="/api/v1/incidents/:id"}`,
+		`redgo_http_requests_total{code="401",method="GET",route="/api/v1/incidents/:id"}`,
+		`redgo_http_requests_total{code="404",method="GET",route="/api/v1/incidents/:id"}`,
+		`redgo_http_requests_total{code="404",method="GET",route="unmatched"}`,
+		`redgo_http_request_duration_seconds_count{method="GET",route="/api/v1/incidents/:id"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics have no %s", want)
+		}
+	}
+	for _, path := range []string{"/api/v1/incidents/1\"", "/api/v1/incidents/999", "/no/such/path"} {
+		if strings.Contains(body, path) {
+			t.Errorf("raw path %s leaked into labels", path)
+		}
+	}
+}
@@ -1,18 +1,155 @@
 package handler
 
 import (
-	"net/http"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
 
+	"github.com/ArtemChadaev/RedGo/internal/domain"
 	"github.com/gin-gonic/gin"
 )
 
-func (h *Handler) apiKeyMiddleware(apiKey string) gin.HandlerFunc {
+const principalCtxKey = "principal"
+
+// requireScope проверяет X-API-KEY или bearer-токен оператора и право на маршрут,
+// кладет автора запроса в контекст и после ответа засчитывает запрос в usage
+func (h *Handler) requireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := h.authenticate(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		// Тенант оператора берется из токена, его надо сверить с базой. У ключей это делает внешний ключ
+		if p.Kind == domain.PrincipalOperator {
+			if _, err := h.services.TenantService.GetTenant(c.Request.Context(), p.TenantID); err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					err = domain.NewForbiddenError("unknown tenant " + p.TenantID)
+				}
+				abortWithError(c, err)
+				return
+			}
+		}
+		if !p.HasScope(scope) {
+			abortWithError(c, domain.NewForbiddenError("api key lacks scope "+string(scope)))
+			return
+		}
+
+		c.Set(principalCtxKey, p)
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), p))
+
+		c.Next()
+
+		// Контекст запроса к этому моменту может быть отменен (SSE, WebSocket)
+		h.services.APIKeyService.RecordUsage(context.WithoutCancel(c.Request.Context()), p, c.Request.Method+" "+c.FullPath())
+	}
+}
+
+// authenticate — машины ходят с X-API-KEY, операторы с Authorization: Bearer <JWT>
+func (h *Handler) authenticate(c *gin.Context) (*domain.Principal, error) {
+	if token, ok := bearerToken(c.GetHeader("Authorization")); ok {
+		return h.services.TokenService.VerifyToken(c.Request.Context(), token)
+	}
+	return h.services.APIKeyService.Authenticate(c.Request.Context(), c.GetHeader("X-API-KEY"))
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// tenantRateLimit ограничивает проверки местоположения лимитом тенанта (rate_limit в минуту)
+func (h *Handler) tenantRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h.services.TenantService.CheckRateLimit(c.Request.Context(), 1); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimit применяет лимиты RATE_LIMITS к маршруту: по ключу, IP и user_id из тела.
+// Ставит X-RateLimit-* по самому строгому из правил, при превышении отвечает 429
+func (h *Handler) rateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		headerKey := c.GetHeader("X-API-KEY")
-		if headerKey != apiKey {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		route := c.Request.Method + " " + c.FullPath()
+		if len(h.services.RateLimitService.Rules(route)) == 0 {
+			c.Next()
+			return
+		}
+
+		var userID string
+		userLoaded := false
+		res, err := h.services.RateLimitService.Apply(c.Request.Context(), route, rateLimitSubject(c, func() string {
+			if !userLoaded {
+				userID, userLoaded = bodyUserID(c), true
+			}
+			return userID
+		}))
+		if res != nil {
+			setRateLimitHeaders(c, res)
+		}
+		if err != nil {
+			abortWithError(c, err)
 			return
 		}
 		c.Next()
 	}
 }
+
+// rateLimitSubject — по чему считать каждое правило: ключ, IP или user_id.
+// userID вызывается, только если у маршрута есть правило user
+func rateLimitSubject(c *gin.Context, userID func() string) func(domain.RateLimitBy) string {
+	return func(by domain.RateLimitBy) string {
+		switch by {
+		case domain.RateLimitByKey:
+			if p := domain.PrincipalFrom(c.Request.Context()); p != nil {
+				return p.ID
+			}
+			return "ip:" + c.ClientIP()
+		case domain.RateLimitByIP:
+			return c.ClientIP()
+		case domain.RateLimitByUser:
+			return userID()
+		}
+		return ""
+	}
+}
+
+func setRateLimitHeaders(c *gin.Context, res *domain.RateLimitResult) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	// Секунды до конца окна, как и Retry-After
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+}
+
+// bodyUserID достает user_id верхнего уровня из JSON-тела и возвращает тело на место для хендлера.
+// Нет поля или тело не JSON — пустая строка, разбираться с телом будет хендлер.
+// У пакетной проверки user_id верхнего уровня нет, правила user она считает сама по позициям
+func bodyUserID(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		UserID json.Number `json:"user_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.UserID.String()
+}
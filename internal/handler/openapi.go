@@ -0,0 +1,26 @@
+package handler
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Спецификация правится руками вместе с Routes и структурами запросов
+//
+//go:embed docs/openapi.json
+var openAPIDocument []byte
+
+//go:embed docs/index.html
+var docsPage []byte
+
+// GET /api/v1/openapi.json
+func (h *Handler) openAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIDocument)
+}
+
+// GET /api/v1/docs
+func (h *Handler) apiDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
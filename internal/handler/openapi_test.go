@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+type openAPISpec struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPISchema struct {
+	Ref        string                     `json:"$ref"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	AllOf      []openAPISchema            `json:"allOf"`
+}
+
+func loadOpenAPI(t *testing.T) *openAPISpec {
+	t.Helper()
+	var spec openAPISpec
+	if err := json.Unmarshal(openAPIDocument, &spec); err != nil {
+		t.Fatalf("docs/openapi.json: %v", err)
+	}
+	return &spec
+}
+
+// operations — "GET /api/v1/incidents/{id}" для каждой операции спецификации с учетом servers пути
+func (s *openAPISpec) operations(t *testing.T) map[string]bool {
+	t.Helper()
+
+	base := ""
+	if len(s.Servers) > 0 {
+		base = s.Servers[0].URL
+	}
+
+	ops := make(map[string]bool)
+	for path, item := range s.Paths {
+		prefix := base
+		if raw, ok := item["servers"]; ok {
+			var servers []struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal(raw, &servers); err != nil {
+				t.Fatalf("%s servers: %v", path, err)
+			}
+			prefix = strings.TrimSuffix(servers[0].URL, "/")
+		}
+		for method := range item {
+			if method == "servers" || method == "parameters" {
+				continue
+			}
+			ops[strings.ToUpper(method)+" "+prefix+path] = true
+		}
+	}
+	return ops
+}
+
+// properties — поля схемы вместе с allOf и $ref
+func (s *openAPISpec) properties(t *testing.T, schema openAPISchema) map[string]bool {
+	t.Helper()
+
+	props := make(map[string]bool)
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		ref, ok := s.Components.Schemas[name]
+		if !ok {
+			t.Fatalf("unknown schema %s", schema.Ref)
+		}
+		return s.properties(t, ref)
+	}
+	for name := range schema.Properties {
+		props[name] = true
+	}
+	for _, part := range schema.AllOf {
+		for name := range s.properties(t, part) {
+			props[name] = true
+		}
+	}
+	return props
+}
+
+// jsonFields — имена json-полей структуры, встроенные структуры раскрываются
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec := loadOpenAPI(t)
+	ops := spec.operations(t)
+	ts := newTestServer(t, nil)
+
+	for _, r := range ts.router.Routes() {
+		op := r.Method + " " + ginParam.ReplaceAllString(r.Path, "{$1}")
+		if !ops[op] {
+			t.Errorf("route %s is missing from docs/openapi.json", op)
+		}
+	}
+}
+
+func TestOpenAPICoversFields(t *testing.T) {
+	spec := loadOpenAPI(t)
+
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{"Incident", domain.Incident{}},
+		{"UpdateIncidentInput", domain.UpdateIncidentInput{}},
+		{"LocationCheckInput", domain.LocationCheck{}},
+		{"LocationCheckInput", streamRequest{}},
+		{"NearbyIncident", domain.NearbyIncident{}},
+		{"LocationCheckResult", domain.LocationCheckResult{}},
+	}
+
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt.value)
+		t.Run(typ.Name(), func(t *testing.T) {
+			schema, ok := spec.Components.Schemas[tt.schema]
+			if !ok {
+				t.Fatalf("schema %s is missing", tt.schema)
+			}
+			props := spec.properties(t, schema)
+			for _, field := range jsonFields(typ) {
+				if !props[field] {
+					t.Errorf("%s.%s is missing from schema %s", typ.Name(), field, tt.schema)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// PATCH /api/v1/incidents/:id
+// JSON Merge Patch (RFC 7396): пришедшие поля заменяются, null сбрасывает map_id к карте по умолчанию.
+// Без If-Match используется версия, прочитанная здесь же, чтобы чужая правка между чтением и записью не потерялась
+func (h *Handler) patchIncident(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, invalidIDError())
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	current, err := h.services.IncidentService.GetIncidentByID(ctx, id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if version == 0 {
+		version = current.Version
+	} else if version != current.Version {
+		abortWithError(c, domain.ErrVersionMismatch)
+		return
+	}
+
+	input, err := mergePatch(patch)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// Пустой патч ничего не меняет и версию не поднимает
+	if len(patch) == 0 {
+		c.Header("ETag", etag(current))
+		c.JSON(http.StatusOK, current)
+		return
+	}
+
+	updated, err := h.services.IncidentService.Update(ctx, id, input, version)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(updated))
+	c.JSON(http.StatusOK, updated)
+}
+
+// mergePatch переводит документ патча в частичное обновление.
+// Поля документа плоские, поэтому рекурсивное слияние из RFC 7396 здесь не нужно
+func mergePatch(patch map[string]json.RawMessage) (domain.UpdateIncidentInput, error) {
+	var input domain.UpdateIncidentInput
+	var fields []domain.FieldError
+
+	for key, raw := range patch {
+		isNull := string(raw) == "null"
+
+		switch key {
+		case "x", "y":
+			if isNull {
+				fields = append(fields, domain.FieldError{Field: key, Message: "cannot be removed"})
+				continue
+			}
+			var v float64
+			if err := json.Unmarshal(raw, &v); err != nil {
+				fields = append(fields, domain.FieldError{Field: key, Message: "must be a number"})
+				continue
+			}
+			if key == "x" {
+				input.X = &v
+			} else {
+				input.Y = &v
+			}
+
+		case "description":
+			// description в схеме не nullable: очистка — явная пустая строка, а не null
+			if isNull {
+				fields = append(fields, domain.FieldError{Field: key, Message: "cannot be null, send \"\" to clear"})
+				continue
+			}
+			var v string
+			if err := json.Unmarshal(raw, &v); err != nil {
+				fields = append(fields, domain.FieldError{Field: key, Message: "must be a string"})
+				continue
+			}
+			input.Description = &v
+
+		case "status":
+			var v domain.IncidentStatus
+			if isNull || json.Unmarshal(raw, &v) != nil || (v != domain.StatusActive && v != domain.StatusInactive) {
+				fields = append(fields, domain.FieldError{Field: key, Message: "must be one of: active inactive"})
+				continue
+			}
+			input.Status = &v
+
+		case "map_id":
+			v := domain.DefaultMapID
+			if !isNull {
+				if err := json.Unmarshal(raw, &v); err != nil || v == "" || len(v) > 64 {
+					fields = append(fields, domain.FieldError{Field: key, Message: "must be a non-empty string up to 64 characters"})
+					continue
+				}
+			}
+			input.MapID = &v
+
+		case "id", "version":
+			fields = append(fields, domain.FieldError{Field: key, Message: "is read-only"})
+
+		default:
+			fields = append(fields, domain.FieldError{Field: key, Message: "unknown field"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return input, domain.NewValidationError(domain.CodeValidation, "invalid merge patch", fields...)
+	}
+
+	return input, nil
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+func TestMergePatch(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	str := func(v string) *string { return &v }
+	status := func(v domain.IncidentStatus) *domain.IncidentStatus { return &v }
+
+	tests := []struct {
+		name  string
+		patch string
+		want  domain.UpdateIncidentInput
+	}{
+		{"empty", `{}`, domain.UpdateIncidentInput{}},
+		{"coordinates", `{"x": 0, "y": -12.5}`, domain.UpdateIncidentInput{X: ptr(0), Y: ptr(-12.5)}},
+		{"description", `{"description": "smoke"}`, domain.UpdateIncidentInput{Description: str("smoke")}},
+		{"empty description clears", `{"description": ""}`, domain.UpdateIncidentInput{Description: str("")}},
+		{"status", `{"status": "inactive"}`, domain.UpdateIncidentInput{Status: status(domain.StatusInactive)}},
+		{"map", `{"map_id": "arena"}`, domain.UpdateIncidentInput{MapID: str("arena")}},
+		{"null map resets", `{"map_id": null}`, domain.UpdateIncidentInput{MapID: str(domain.DefaultMapID)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+			got, err := mergePatch(patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchErrors(t *testing.T) {
+	tests := []struct {
+		patch  string
+		fields []string
+	}{
+		{`{"x": null}`, []string{"x"}},
+		{`{"y": "north"}`, []string{"y"}},
+		{`{"description": 5}`, []string{"description"}},
+		{`{"description": null}`, []string{"description"}},
+		{`{"status": null}`, []string{"status"}},
+		{`{"status": "closed"}`, []string{"status"}},
+		{`{"map_id": ""}`, []string{"map_id"}},
+		{`{"id": 2, "version": 9}`, []string{"id", "version"}},
+		{`{"color": "red", "x": 1}`, []string{"color"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			var patch map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+			_, err := mergePatch(patch)
+
+			var derr *domain.Error
+			if !errors.As(err, &derr) || !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("want validation error, got %v", err)
+			}
+			var fields []string
+			for _, f := range derr.Fields {
+				fields = append(fields, f.Field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("fields %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestPatchIncident(t *testing.T) {
+	ts := newTestServer(t, nil)
+	withIncident(ts)
+
+	rec := ts.do(http.MethodPatch, "/api/v1/incidents/1", `{"status": "inactive", "description": ""}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got domain.Incident
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.StatusInactive || got.Description != "" || *got.X != 1 || got.Version != 4 {
+		t.Errorf("patched incident %+v", got)
+	}
+
+	// Пустой патч версию не поднимает
+	rec = ts.do(http.MethodPatch, "/api/v1/incidents/1", `{}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"4"` {
+		t.Errorf("empty patch: status %d, ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	rec = ts.do(http.MethodPatch, "/api/v1/incidents/1", `{"x": null}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid patch: status %d", rec.Code)
+	}
+
+	rec = ts.do(http.MethodPatch, "/api/v1/incidents/2", `{"description": "x"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing incident: status %d", rec.Code)
+	}
+}
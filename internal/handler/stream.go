@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamWriteWait      = 10 * time.Second        // сколько ждем запись одного сообщения
+	streamPongWait       = 60 * time.Second        // без pong дольше этого считаем клиента отвалившимся
+	streamPingPeriod     = streamPongWait * 9 / 10 // пингуем чуть чаще, чем ждем pong
+	streamMaxMessageSize = 4096                    // позиция игрока — маленький JSON
+)
+
+const (
+	streamTypeResult = "result"
+	streamTypeEnter  = "enter"
+	streamTypeExit   = "exit"
+	streamTypeError  = "error"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Клиенты — игровые серверы и мобильные приложения, а не браузер со сторонних сайтов
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamRequest — обновление позиции, те же поля, что у POST /location/check
+// Координаты указателями: required на float64 отвергает 0, а это экватор и нулевой меридиан в wgs84
+type streamRequest struct {
+	UserID         int      `json:"user_id" binding:"required"`
+	MapID          string   `json:"map_id" binding:"omitempty,max=64"`
+	X              *float64 `json:"x" binding:"required"`
+	Y              *float64 `json:"y" binding:"required"`
+	Limit          int      `json:"limit" binding:"omitempty,min=0"`
+	IncludeNearest bool     `json:"include_nearest"`
+}
+
+// streamMessage — всё, что сервер пушит в соединение. Поле type определяет, какие поля заполнены
+type streamMessage struct {
+	Type       string                  `json:"type"`
+	UserID     int                     `json:"user_id,omitempty"`
+	Incidents  []domain.NearbyIncident `json:"incidents,omitempty"`
+	Warning    *domain.NearbyIncident  `json:"warning,omitempty"`
+	Degraded   bool                    `json:"degraded,omitempty"`
+	Incident   *domain.NearbyIncident  `json:"incident,omitempty"`
+	IncidentID int                     `json:"incident_id,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+	Code       string                  `json:"code,omitempty"`
+	Fields     []domain.FieldError     `json:"errors,omitempty"`
+}
+
+// GET /api/v1/location/stream (WebSocket)
+// Клиент шлет позиции, в ответ получает result и события enter/exit по каждому user_id.
+// Одно соединение может вести нескольких игроков (игровой сервер)
+func (h *Handler) streamLocation(c *gin.Context) {
+	if !h.trackStream() {
+		abortWithError(c, domain.NewUnavailableError("server is shutting down", nil))
+		return
+	}
+	defer h.streams.Done()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade сам ответил клиенту ошибкой
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(streamMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go streamPing(conn, done, h.stopStreams)
+
+	ctx := c.Request.Context()
+	streamRoute := c.Request.Method + " " + c.FullPath()
+
+	// inside[user_id] — инциденты, в радиусе которых игрок был на прошлом шаге
+	inside := make(map[int]map[int]struct{})
+
+	for {
+		var req streamRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				slog.InfoContext(ctx, "location stream closed", logging.Err(err))
+			}
+			return
+		}
+
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			if !streamWrite(conn, streamError(c, req.UserID, bindError(err))) {
+				return
+			}
+			continue
+		}
+
+		// Каждое сообщение — такая же проверка, как POST /location/check: лимит тенанта
+		// и все правила маршрута. Иначе одно соединение обходит лимиты по ключу и IP
+		if err := h.streamRateLimit(c, streamRoute, req.UserID); err != nil {
+			if !streamWrite(conn, streamError(c, req.UserID, err)) {
+				return
+			}
+			continue
+		}
+
+		// limit применяем сами: enter/exit должны считаться по всем совпадениям
+		res, err := h.services.IncidentService.CheckLocation(ctx, domain.LocationCheck{
+			UserID: req.UserID,
+			MapID:  req.MapID,
+			X:      *req.X,
+			Y:      *req.Y,
+		}, domain.CheckOptions{IncludeNearest: req.IncludeNearest})
+		if err != nil {
+			if !streamWrite(conn, streamError(c, req.UserID, err)) {
+				return
+			}
+			continue
+		}
+
+		events := diffInside(inside, req.UserID, res.Incidents)
+
+		matches := res.Incidents
+		if req.Limit > 0 && len(matches) > req.Limit {
+			matches = matches[:req.Limit]
+		}
+
+		if !streamWrite(conn, streamMessage{
+			Type:      streamTypeResult,
+			UserID:    req.UserID,
+			Incidents: matches,
+			Warning:   res.Warning,
+			Degraded:  res.Degraded,
+		}) {
+			return
+		}
+
+		for _, ev := range events {
+			if !streamWrite(conn, ev) {
+				return
+			}
+		}
+	}
+}
+
+// streamRateLimit считает сообщение стрима в лимит тенанта и в правила RATE_LIMITS маршрута
+func (h *Handler) streamRateLimit(c *gin.Context, route string, userID int) error {
+	ctx := c.Request.Context()
+	if err := h.services.TenantService.CheckRateLimit(ctx, 1); err != nil {
+		return err
+	}
+	_, err := h.services.RateLimitService.Apply(ctx, route, rateLimitSubject(c, func() string {
+		return strconv.Itoa(userID)
+	}))
+	return err
+}
+
+// diffInside обновляет множество инцидентов игрока и возвращает события входа и выхода
+func diffInside(inside map[int]map[int]struct{}, userID int, matches []domain.NearbyIncident) []streamMessage {
+	prev := inside[userID]
+	next := make(map[int]struct{}, len(matches))
+	var events []streamMessage
+
+	for i := range matches {
+		id := matches[i].ID
+		next[id] = struct{}{}
+		if _, ok := prev[id]; !ok {
+			events = append(events, streamMessage{Type: streamTypeEnter, UserID: userID, Incident: &matches[i]})
+		}
+	}
+
+	for id := range prev {
+		if _, ok := next[id]; !ok {
+			events = append(events, streamMessage{Type: streamTypeExit, UserID: userID, IncidentID: id})
+		}
+	}
+
+	inside[userID] = next
+	return events
+}
+
+// streamError — та же модель ошибок, что и в REST, только внутри сообщения
+func streamError(c *gin.Context, userID int, err error) streamMessage {
+	p := newProblem(c, err)
+	return streamMessage{
+		Type:   streamTypeError,
+		UserID: userID,
+		Error:  p.Detail,
+		Code:   p.Code,
+		Fields: p.Errors,
+	}
+}
+
+// streamWrite пишет сообщение с дедлайном. false — соединение больше не пригодно
+func streamWrite(conn *websocket.Conn, msg streamMessage) bool {
+	_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	return conn.WriteJSON(msg) == nil
+}
+
+// streamPing держит соединение живым, а при остановке сервера закрывает его: ReadJSON в цикле стрима
+// вернет ошибку, и обработчик выйдет. WriteControl и Close можно вызывать параллельно с WriteJSON
+func streamPing(conn *websocket.Conn, done, stop <-chan struct{}) {
+	ticker := time.NewTicker(streamPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-stop:
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"), time.Now().Add(streamWriteWait))
+			_ = conn.Close()
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
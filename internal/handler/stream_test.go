@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/gorilla/websocket"
+)
+
+const streamPath = "/api/v1/location/stream"
+
+func streamRule(by domain.RateLimitBy, limit int) domain.RateLimitRule {
+	return domain.RateLimitRule{Route: http.MethodGet + " " + streamPath, By: by, Limit: limit, Window: time.Minute}
+}
+
+func dialStream(t *testing.T, ts *testServer) *websocket.Conn {
+	t.Helper()
+
+	srv := httptest.NewServer(ts.router)
+	t.Cleanup(srv.Close)
+
+	conn, resp, err := dialURL(srv.URL)
+	if err != nil {
+		t.Fatalf("dial: %v (response %+v)", err, resp)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func dialURL(serverURL string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	header.Set("X-API-KEY", testAPIKey)
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(serverURL, "http")+streamPath, header)
+}
+
+// sendPosition шлет позицию и возвращает первое сообщение в ответ
+func sendPosition(t *testing.T, conn *websocket.Conn, userID int) streamMessage {
+	t.Helper()
+
+	if err := conn.WriteJSON(map[string]any{"user_id": userID, "x": 1, "y": 1}); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg streamMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestStreamRateLimitPerMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []domain.RateLimitRule
+		// users — user_id сообщений по порядку, allowed — какие из них пройдут
+		users   []int
+		allowed []bool
+	}{
+		{
+			// Апгрейд соединения тоже считается запросом по ключу
+			name:    "key",
+			rules:   []domain.RateLimitRule{streamRule(domain.RateLimitByKey, 3)},
+			users:   []int{1, 2, 3, 4},
+			allowed: []bool{true, true, false, false},
+		},
+		{
+			name:    "ip",
+			rules:   []domain.RateLimitRule{streamRule(domain.RateLimitByIP, 2)},
+			users:   []int{1, 2, 3},
+			allowed: []bool{true, false, false},
+		},
+		{
+			name:    "user",
+			rules:   []domain.RateLimitRule{streamRule(domain.RateLimitByUser, 2)},
+			users:   []int{1, 1, 1, 2},
+			allowed: []bool{true, true, false, true},
+		},
+		{
+			name:    "all rules",
+			rules:   []domain.RateLimitRule{streamRule(domain.RateLimitByKey, 100), streamRule(domain.RateLimitByIP, 100), streamRule(domain.RateLimitByUser, 1)},
+			users:   []int{1, 2, 1},
+			allowed: []bool{true, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, tt.rules)
+			conn := dialStream(t, ts)
+
+			for i, userID := range tt.users {
+				msg := sendPosition(t, conn, userID)
+				if tt.allowed[i] {
+					if msg.Type != streamTypeResult {
+						t.Fatalf("message %d: got %+v, want result", i, msg)
+					}
+					continue
+				}
+				if msg.Type != streamTypeError || msg.Code != domain.CodeRateLimited || msg.UserID != userID {
+					t.Fatalf("message %d: got %+v, want rate_limited error", i, msg)
+				}
+			}
+
+			var passed int
+			for _, ok := range tt.allowed {
+				if ok {
+					passed++
+				}
+			}
+			if got := len(ts.incidents.savedChecks()); got != passed {
+				t.Errorf("checked %d positions, want %d", got, passed)
+			}
+		})
+	}
+}
+
+func TestStreamTenantRateLimitPerMessage(t *testing.T) {
+	ts := newTestServer(t, nil)
+	// Одна проверка уходит на апгрейд, дальше по одной на сообщение
+	ts.tenants.limit = 3
+	conn := dialStream(t, ts)
+
+	for i, want := range []string{streamTypeResult, streamTypeResult, streamTypeError} {
+		msg := sendPosition(t, conn, i+1)
+		if msg.Type != want {
+			t.Fatalf("message %d: got %+v, want %s", i, msg, want)
+		}
+		if want == streamTypeError && msg.Code != domain.CodeRateLimited {
+			t.Errorf("message %d: code %q, want %q", i, msg.Code, domain.CodeRateLimited)
+		}
+	}
+}
+
+func TestCheckLocationRateLimit(t *testing.T) {
+	route := http.MethodPost + " /api/v1/location/check"
+	ts := newTestServer(t, []domain.RateLimitRule{
+		{Route: route, By: domain.RateLimitByKey, Limit: 10, Window: time.Minute},
+		{Route: route, By: domain.RateLimitByUser, Limit: 2, Window: time.Minute},
+	})
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rec := ts.do(http.MethodPost, "/api/v1/location/check", map[string]any{"user_id": 1, "x": 1, "y": 1})
+		if rec.Code != want {
+			t.Fatalf("request %d: status %d, want %d: %s", i, rec.Code, want, rec.Body)
+		}
+		if want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+
+	// Другой игрок упирается только в лимит ключа; заголовки — по самому строгому правилу
+	rec := ts.do(http.MethodPost, "/api/v1/location/check", map[string]any{"user_id": 2, "x": 1, "y": 1})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 1", got)
+	}
+}
+
+func TestCloseStreams(t *testing.T) {
+	ts := newTestServer(t, nil)
+	srv := httptest.NewServer(ts.router)
+	t.Cleanup(srv.Close)
+
+	conn, _, err := dialURL(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if msg := sendPosition(t, conn, 1); msg.Type != streamTypeResult {
+		t.Fatalf("got %+v, want result", msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ts.handler.CloseStreams(ctx); err != nil {
+		t.Fatalf("CloseStreams: %v", err)
+	}
+
+	// Обработчик вышел: соединение закрыто сервером с going away, и проверок больше не будет
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("read after CloseStreams: %v, want going away", err)
+	}
+	if n := len(ts.incidents.savedChecks()); n != 1 {
+		t.Errorf("saved %d checks, want 1", n)
+	}
+
+	// Новые стримы во время остановки не открываются
+	if _, resp, err := dialURL(srv.URL); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("dial during shutdown: %v (response %+v), want 503", err, resp)
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/v1/admin/tenants
+func (h *Handler) createTenant(c *gin.Context) {
+	var input domain.CreateTenantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	tenant, err := h.services.TenantService.CreateTenant(c.Request.Context(), input)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// GET /api/v1/admin/tenants
+func (h *Handler) listTenants(c *gin.Context) {
+	tenants, err := h.services.TenantService.ListTenants(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenants)
+}
+
+// PATCH /api/v1/admin/tenants/:id — вебхук и лимиты тенанта, пустой webhook_url возвращает общий
+func (h *Handler) updateTenant(c *gin.Context) {
+	var input domain.UpdateTenantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	tenant, err := h.services.TenantService.UpdateTenant(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
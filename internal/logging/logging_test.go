@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	tests := []struct {
+		level, format string
+		ok            bool
+	}{
+		{"", "", true},
+		{"debug", "json", true},
+		{"WARN", "text", true},
+		{"error", "JSON", true},
+		{"verbose", "json", false},
+		{"info", "xml", false},
+	}
+
+	for _, tt := range tests {
+		err := Setup(&bytes.Buffer{}, tt.level, tt.format)
+		if (err == nil) != tt.ok {
+			t.Errorf("Setup(%q, %q): err = %v, want ok = %v", tt.level, tt.format, err, tt.ok)
+		}
+	}
+}
+
+func TestContextAttrs(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithDeliveryID(WithRequestID(context.Background(), "req-1"), "d-1")
+	slog.InfoContext(ctx, "hello")
+	slog.DebugContext(ctx, "below level")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %s", len(lines), buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["msg"] != "hello" || rec["level"] != "INFO" || rec["request_id"] != "req-1" || rec["delivery_id"] != "d-1" {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abc-123", true},
+		{strings.Repeat("a", 128), true},
+		{"", false},
+		{strings.Repeat("a", 129), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"юникод", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
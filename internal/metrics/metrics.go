@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "redgo"
+
+// Registry — свой реестр вместо глобального: в /metrics попадает только то, что зарегистрировали мы
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by gin route and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by gin route. Streams are counted until the connection closes.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	LocationMatches = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "location_check_matches",
+		Help:      "Incidents within the detection radius per location check.",
+		Buckets:   []float64{0, 1, 2, 3, 5, 10, 20, 50},
+	})
+
+	DegradedChecks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "location_checks_degraded_total",
+		Help:      "Location checks answered without Postgres: not saved or matched against the last known active set.",
+	})
+
+	CheckBufferPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "location_check_buffer_pending",
+		Help:      "Location checks waiting in the write-behind buffer.",
+	})
+
+	CheckBufferDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "location_check_buffer_dropped_total",
+		Help:      "Buffered location checks that could not be written and were discarded.",
+	})
+
+	CheckPartitionsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "location_check_partitions_dropped_total",
+		Help:      "Daily location_checks partitions dropped by the retention job.",
+	})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by RATE_LIMITS rules.",
+	}, []string{"route", "by"})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts by response status class (2xx, 4xx, 5xx, error).",
+	}, []string{"status_class"})
+
+	WebhookDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_delivery_duration_seconds",
+		Help:      "Webhook delivery latency by response status class.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status_class"})
+
+	WebhookRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_retries_total",
+		Help:      "Failed webhook deliveries scheduled for retry.",
+	})
+
+	WebhookDeadLettered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_dead_lettered_total",
+		Help:      "Webhook tasks moved to the DLQ after the last retry.",
+	})
+
+	ScalingEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_scaling_events_total",
+		Help:      "Autoscaler decisions by direction (up, down).",
+	}, []string{"direction"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration,
+		LocationMatches, DegradedChecks, RateLimitRejections,
+		CheckBufferPending, CheckBufferDropped, CheckPartitionsDropped,
+		WebhookDeliveries, WebhookDuration, WebhookRetries, WebhookDeadLettered,
+		ScalingEvents,
+	)
+}
+
+// RegisterPools добавляет статистику пулов соединений Postgres и Redis
+func RegisterPools(db *sql.DB, rdb *redis.Client) {
+	Registry.MustRegister(
+		collectors.NewDBStatsCollector(db, "postgres"),
+		&redisPoolCollector{client: rdb},
+	)
+}
+
+// StatusClass сводит код ответа к 2xx/3xx/4xx/5xx, 0 — ответа не было
+func StatusClass(code int) string {
+	switch {
+	case code <= 0:
+		return "error"
+	case code < 300:
+		return "2xx"
+	case code < 400:
+		return "3xx"
+	case code < 500:
+		return "4xx"
+	default:
+		return "5xx"
+	}
+}
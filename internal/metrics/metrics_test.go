@@ -0,0 +1,25 @@
+package metrics
+
+import "testing"
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{0, "error"},
+		{200, "2xx"},
+		{204, "2xx"},
+		{302, "3xx"},
+		{404, "4xx"},
+		{429, "4xx"},
+		{500, "5xx"},
+		{503, "5xx"},
+	}
+
+	for _, tt := range tests {
+		if got := StatusClass(tt.code); got != tt.want {
+			t.Errorf("StatusClass(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1014 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: redgo/v1/incident.proto
+
+package redgov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Incident struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	X             float64                `protobuf:"fixed64,3,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,4,opt,name=y,proto3" json:"y,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	MapId         string                 `protobuf:"bytes,6,opt,name=map_id,json=mapId,proto3" json:"map_id,omitempty"`
+	Version       int64                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Incident) Reset() {
+	*x = Incident{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Incident) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Incident) ProtoMessage() {}
+
+func (x *Incident) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Incident.ProtoReflect.Descriptor instead.
+func (*Incident) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Incident) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Incident) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Incident) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Incident) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Incident) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Incident) GetMapId() string {
+	if x != nil {
+		return x.MapId
+	}
+	return ""
+}
+
+func (x *Incident) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type CreateIncidentRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Description string                 `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	X           float64                `protobuf:"fixed64,2,opt,name=x,proto3" json:"x,omitempty"`
+	Y           float64                `protobuf:"fixed64,3,opt,name=y,proto3" json:"y,omitempty"`
+	// active (по умолчанию) или inactive
+	Status        string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	MapId         string `protobuf:"bytes,5,opt,name=map_id,json=mapId,proto3" json:"map_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateIncidentRequest) Reset() {
+	*x = CreateIncidentRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateIncidentRequest) ProtoMessage() {}
+
+func (x *CreateIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateIncidentRequest.ProtoReflect.Descriptor instead.
+func (*CreateIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateIncidentRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateIncidentRequest) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *CreateIncidentRequest) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *CreateIncidentRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CreateIncidentRequest) GetMapId() string {
+	if x != nil {
+		return x.MapId
+	}
+	return ""
+}
+
+type GetIncidentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetIncidentRequest) Reset() {
+	*x = GetIncidentRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIncidentRequest) ProtoMessage() {}
+
+func (x *GetIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIncidentRequest.ProtoReflect.Descriptor instead.
+func (*GetIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetIncidentRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListIncidentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	MapId         string                 `protobuf:"bytes,3,opt,name=map_id,json=mapId,proto3" json:"map_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIncidentsRequest) Reset() {
+	*x = ListIncidentsRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIncidentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncidentsRequest) ProtoMessage() {}
+
+func (x *ListIncidentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncidentsRequest.ProtoReflect.Descriptor instead.
+func (*ListIncidentsRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListIncidentsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListIncidentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListIncidentsRequest) GetMapId() string {
+	if x != nil {
+		return x.MapId
+	}
+	return ""
+}
+
+type ListIncidentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Incidents     []*Incident            `protobuf:"bytes,1,rep,name=incidents,proto3" json:"incidents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIncidentsResponse) Reset() {
+	*x = ListIncidentsResponse{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIncidentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncidentsResponse) ProtoMessage() {}
+
+func (x *ListIncidentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncidentsResponse.ProtoReflect.Descriptor instead.
+func (*ListIncidentsResponse) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListIncidentsResponse) GetIncidents() []*Incident {
+	if x != nil {
+		return x.Incidents
+	}
+	return nil
+}
+
+// UpdateIncidentRequest — частичное обновление, как PUT: меняются только заданные поля
+type UpdateIncidentRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	X           *float64               `protobuf:"fixed64,2,opt,name=x,proto3,oneof" json:"x,omitempty"`
+	Y           *float64               `protobuf:"fixed64,3,opt,name=y,proto3,oneof" json:"y,omitempty"`
+	Description *string                `protobuf:"bytes,4,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Status      *string                `protobuf:"bytes,5,opt,name=status,proto3,oneof" json:"status,omitempty"`
+	MapId       *string                `protobuf:"bytes,6,opt,name=map_id,json=mapId,proto3,oneof" json:"map_id,omitempty"`
+	// Ожидаемая версия, как If-Match в REST: не совпала — FAILED_PRECONDITION. 0 — без проверки
+	Version       int64 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateIncidentRequest) Reset() {
+	*x = UpdateIncidentRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateIncidentRequest) ProtoMessage() {}
+
+func (x *UpdateIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateIncidentRequest.ProtoReflect.Descriptor instead.
+func (*UpdateIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateIncidentRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateIncidentRequest) GetX() float64 {
+	if x != nil && x.X != nil {
+		return *x.X
+	}
+	return 0
+}
+
+func (x *UpdateIncidentRequest) GetY() float64 {
+	if x != nil && x.Y != nil {
+		return *x.Y
+	}
+	return 0
+}
+
+func (x *UpdateIncidentRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateIncidentRequest) GetStatus() string {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return ""
+}
+
+func (x *UpdateIncidentRequest) GetMapId() string {
+	if x != nil && x.MapId != nil {
+		return *x.MapId
+	}
+	return ""
+}
+
+func (x *UpdateIncidentRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteIncidentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Ожидаемая версия, как If-Match в REST. 0 — без проверки
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteIncidentRequest) Reset() {
+	*x = DeleteIncidentRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteIncidentRequest) ProtoMessage() {}
+
+func (x *DeleteIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteIncidentRequest.ProtoReflect.Descriptor instead.
+func (*DeleteIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteIncidentRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteIncidentRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{7}
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserCount     int64                  `protobuf:"varint,1,opt,name=user_count,json=userCount,proto3" json:"user_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetStatsResponse) GetUserCount() int64 {
+	if x != nil {
+		return x.UserCount
+	}
+	return 0
+}
+
+type CheckLocationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MapId          string                 `protobuf:"bytes,2,opt,name=map_id,json=mapId,proto3" json:"map_id,omitempty"`
+	X              float64                `protobuf:"fixed64,3,opt,name=x,proto3" json:"x,omitempty"`
+	Y              float64                `protobuf:"fixed64,4,opt,name=y,proto3" json:"y,omitempty"`
+	Limit          int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	IncludeNearest bool                   `protobuf:"varint,6,opt,name=include_nearest,json=includeNearest,proto3" json:"include_nearest,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CheckLocationRequest) Reset() {
+	*x = CheckLocationRequest{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckLocationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckLocationRequest) ProtoMessage() {}
+
+func (x *CheckLocationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckLocationRequest.ProtoReflect.Descriptor instead.
+func (*CheckLocationRequest) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CheckLocationRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *CheckLocationRequest) GetMapId() string {
+	if x != nil {
+		return x.MapId
+	}
+	return ""
+}
+
+func (x *CheckLocationRequest) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *CheckLocationRequest) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *CheckLocationRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *CheckLocationRequest) GetIncludeNearest() bool {
+	if x != nil {
+		return x.IncludeNearest
+	}
+	return false
+}
+
+type NearbyIncident struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Incident      *Incident              `protobuf:"bytes,1,opt,name=incident,proto3" json:"incident,omitempty"`
+	Distance      float64                `protobuf:"fixed64,2,opt,name=distance,proto3" json:"distance,omitempty"`
+	Bearing       float64                `protobuf:"fixed64,3,opt,name=bearing,proto3" json:"bearing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NearbyIncident) Reset() {
+	*x = NearbyIncident{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NearbyIncident) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyIncident) ProtoMessage() {}
+
+func (x *NearbyIncident) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyIncident.ProtoReflect.Descriptor instead.
+func (*NearbyIncident) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *NearbyIncident) GetIncident() *Incident {
+	if x != nil {
+		return x.Incident
+	}
+	return nil
+}
+
+func (x *NearbyIncident) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *NearbyIncident) GetBearing() float64 {
+	if x != nil {
+		return x.Bearing
+	}
+	return 0
+}
+
+type CheckLocationResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	UserId    int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Incidents []*NearbyIncident      `protobuf:"bytes,2,rep,name=incidents,proto3" json:"incidents,omitempty"`
+	// Ближайший инцидент вне радиуса, если запрошен include_nearest
+	Warning *NearbyIncident `protobuf:"bytes,3,opt,name=warning,proto3" json:"warning,omitempty"`
+	// Postgres недоступен: проверка не сохранена или ответ по последнему известному набору инцидентов
+	Degraded bool `protobuf:"varint,4,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	// Только в StreamLocation: позиция не проверена, остальные поля пустые
+	Error         *CheckError `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckLocationResponse) Reset() {
+	*x = CheckLocationResponse{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckLocationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckLocationResponse) ProtoMessage() {}
+
+func (x *CheckLocationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckLocationResponse.ProtoReflect.Descriptor instead.
+func (*CheckLocationResponse) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CheckLocationResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *CheckLocationResponse) GetIncidents() []*NearbyIncident {
+	if x != nil {
+		return x.Incidents
+	}
+	return nil
+}
+
+func (x *CheckLocationResponse) GetWarning() *NearbyIncident {
+	if x != nil {
+		return x.Warning
+	}
+	return nil
+}
+
+func (x *CheckLocationResponse) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+func (x *CheckLocationResponse) GetError() *CheckError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// CheckError — та же модель ошибок, что и в REST: стабильный код и сообщение
+type CheckError struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Code    string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Для rate_limited — через сколько секунд повторить
+	RetryAfterSeconds int64 `protobuf:"varint,3,opt,name=retry_after_seconds,json=retryAfterSeconds,proto3" json:"retry_after_seconds,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CheckError) Reset() {
+	*x = CheckError{}
+	mi := &file_redgo_v1_incident_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckError) ProtoMessage() {}
+
+func (x *CheckError) ProtoReflect() protoreflect.Message {
+	mi := &file_redgo_v1_incident_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckError.ProtoReflect.Descriptor instead.
+func (*CheckError) Descriptor() ([]byte, []int) {
+	return file_redgo_v1_incident_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CheckError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *CheckError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CheckError) GetRetryAfterSeconds() int64 {
+	if x != nil {
+		return x.RetryAfterSeconds
+	}
+	return 0
+}
+
+var File_redgo_v1_incident_proto protoreflect.FileDescriptor
+
+const file_redgo_v1_incident_proto_rawDesc = "" +
+	"\n" +
+	"\x17redgo/v1/incident.proto\x12\bredgo.v1\x1a\x1bgoogle/protobuf/empty.proto\"\xa1\x01\n" +
+	"\bIncident\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\f\n" +
+	"\x01x\x18\x03 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x04 \x01(\x01R\x01y\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x15\n" +
+	"\x06map_id\x18\x06 \x01(\tR\x05mapId\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\"\x84\x01\n" +
+	"\x15CreateIncidentRequest\x12 \n" +
+	"\vdescription\x18\x01 \x01(\tR\vdescription\x12\f\n" +
+	"\x01x\x18\x02 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x03 \x01(\x01R\x01y\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x15\n" +
+	"\x06map_id\x18\x05 \x01(\tR\x05mapId\"$\n" +
+	"\x12GetIncidentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"^\n" +
+	"\x14ListIncidentsRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x15\n" +
+	"\x06map_id\x18\x03 \x01(\tR\x05mapId\"I\n" +
+	"\x15ListIncidentsResponse\x120\n" +
+	"\tincidents\x18\x01 \x03(\v2\x12.redgo.v1.IncidentR\tincidents\"\xf9\x01\n" +
+	"\x15UpdateIncidentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x11\n" +
+	"\x01x\x18\x02 \x01(\x01H\x00R\x01x\x88\x01\x01\x12\x11\n" +
+	"\x01y\x18\x03 \x01(\x01H\x01R\x01y\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x04 \x01(\tH\x02R\vdescription\x88\x01\x01\x12\x1b\n" +
+	"\x06status\x18\x05 \x01(\tH\x03R\x06status\x88\x01\x01\x12\x1a\n" +
+	"\x06map_id\x18\x06 \x01(\tH\x04R\x05mapId\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversionB\x04\n" +
+	"\x02_xB\x04\n" +
+	"\x02_yB\x0e\n" +
+	"\f_descriptionB\t\n" +
+	"\a_statusB\t\n" +
+	"\a_map_id\"A\n" +
+	"\x15DeleteIncidentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\x11\n" +
+	"\x0fGetStatsRequest\"1\n" +
+	"\x10GetStatsResponse\x12\x1d\n" +
+	"\n" +
+	"user_count\x18\x01 \x01(\x03R\tuserCount\"\xa1\x01\n" +
+	"\x14CheckLocationRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x15\n" +
+	"\x06map_id\x18\x02 \x01(\tR\x05mapId\x12\f\n" +
+	"\x01x\x18\x03 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x04 \x01(\x01R\x01y\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12'\n" +
+	"\x0finclude_nearest\x18\x06 \x01(\bR\x0eincludeNearest\"v\n" +
+	"\x0eNearbyIncident\x12.\n" +
+	"\bincident\x18\x01 \x01(\v2\x12.redgo.v1.IncidentR\bincident\x12\x1a\n" +
+	"\bdistance\x18\x02 \x01(\x01R\bdistance\x12\x18\n" +
+	"\abearing\x18\x03 \x01(\x01R\abearing\"\xe4\x01\n" +
+	"\x15CheckLocationResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x126\n" +
+	"\tincidents\x18\x02 \x03(\v2\x18.redgo.v1.NearbyIncidentR\tincidents\x122\n" +
+	"\awarning\x18\x03 \x01(\v2\x18.redgo.v1.NearbyIncidentR\awarning\x12\x1a\n" +
+	"\bdegraded\x18\x04 \x01(\bR\bdegraded\x12*\n" +
+	"\x05error\x18\x05 \x01(\v2\x14.redgo.v1.CheckErrorR\x05error\"j\n" +
+	"\n" +
+	"CheckError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12.\n" +
+	"\x13retry_after_seconds\x18\x03 \x01(\x03R\x11retryAfterSeconds2\xe9\x04\n" +
+	"\x0fIncidentService\x12E\n" +
+	"\x0eCreateIncident\x12\x1f.redgo.v1.CreateIncidentRequest\x1a\x12.redgo.v1.Incident\x12?\n" +
+	"\vGetIncident\x12\x1c.redgo.v1.GetIncidentRequest\x1a\x12.redgo.v1.Incident\x12P\n" +
+	"\rListIncidents\x12\x1e.redgo.v1.ListIncidentsRequest\x1a\x1f.redgo.v1.ListIncidentsResponse\x12E\n" +
+	"\x0eUpdateIncident\x12\x1f.redgo.v1.UpdateIncidentRequest\x1a\x12.redgo.v1.Incident\x12I\n" +
+	"\x0eDeleteIncident\x12\x1f.redgo.v1.DeleteIncidentRequest\x1a\x16.google.protobuf.Empty\x12A\n" +
+	"\bGetStats\x12\x19.redgo.v1.GetStatsRequest\x1a\x1a.redgo.v1.GetStatsResponse\x12P\n" +
+	"\rCheckLocation\x12\x1e.redgo.v1.CheckLocationRequest\x1a\x1f.redgo.v1.CheckLocationResponse\x12U\n" +
+	"\x0eStreamLocation\x12\x1e.redgo.v1.CheckLocationRequest\x1a\x1f.redgo.v1.CheckLocationResponse(\x010\x01B;Z9github.com/ArtemChadaev/RedGo/internal/pb/redgov1;redgov1b\x06proto3"
+
+var (
+	file_redgo_v1_incident_proto_rawDescOnce sync.Once
+	file_redgo_v1_incident_proto_rawDescData []byte
+)
+
+func file_redgo_v1_incident_proto_rawDescGZIP() []byte {
+	file_redgo_v1_incident_proto_rawDescOnce.Do(func() {
+		file_redgo_v1_incident_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_redgo_v1_incident_proto_rawDesc), len(file_redgo_v1_incident_proto_rawDesc)))
+	})
+	return file_redgo_v1_incident_proto_rawDescData
+}
+
+var file_redgo_v1_incident_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_redgo_v1_incident_proto_goTypes = []any{
+	(*Incident)(nil),              // 0: redgo.v1.Incident
+	(*CreateIncidentRequest)(nil), // 1: redgo.v1.CreateIncidentRequest
+	(*GetIncidentRequest)(nil),    // 2: redgo.v1.GetIncidentRequest
+	(*ListIncidentsRequest)(nil),  // 3: redgo.v1.ListIncidentsRequest
+	(*ListIncidentsResponse)(nil), // 4: redgo.v1.ListIncidentsResponse
+	(*UpdateIncidentRequest)(nil), // 5: redgo.v1.UpdateIncidentRequest
+	(*DeleteIncidentRequest)(nil), // 6: redgo.v1.DeleteIncidentRequest
+	(*GetStatsRequest)(nil),       // 7: redgo.v1.GetStatsRequest
+	(*GetStatsResponse)(nil),      // 8: redgo.v1.GetStatsResponse
+	(*CheckLocationRequest)(nil),  // 9: redgo.v1.CheckLocationRequest
+	(*NearbyIncident)(nil),        // 10: redgo.v1.NearbyIncident
+	(*CheckLocationResponse)(nil), // 11: redgo.v1.CheckLocationResponse
+	(*CheckError)(nil),            // 12: redgo.v1.CheckError
+	(*emptypb.Empty)(nil),         // 13: google.protobuf.Empty
+}
+var file_redgo_v1_incident_proto_depIdxs = []int32{
+	0,  // 0: redgo.v1.ListIncidentsResponse.incidents:type_name -> redgo.v1.Incident
+	0,  // 1: redgo.v1.NearbyIncident.incident:type_name -> redgo.v1.Incident
+	10, // 2: redgo.v1.CheckLocationResponse.incidents:type_name -> redgo.v1.NearbyIncident
+	10, // 3: redgo.v1.CheckLocationResponse.warning:type_name -> redgo.v1.NearbyIncident
+	12, // 4: redgo.v1.CheckLocationResponse.error:type_name -> redgo.v1.CheckError
+	1,  // 5: redgo.v1.IncidentService.CreateIncident:input_type -> redgo.v1.CreateIncidentRequest
+	2,  // 6: redgo.v1.IncidentService.GetIncident:input_type -> redgo.v1.GetIncidentRequest
+	3,  // 7: redgo.v1.IncidentService.ListIncidents:input_type -> redgo.v1.ListIncidentsRequest
+	5,  // 8: redgo.v1.IncidentService.UpdateIncident:input_type -> redgo.v1.UpdateIncidentRequest
+	6,  // 9: redgo.v1.IncidentService.DeleteIncident:input_type -> redgo.v1.DeleteIncidentRequest
+	7,  // 10: redgo.v1.IncidentService.GetStats:input_type -> redgo.v1.GetStatsRequest
+	9,  // 11: redgo.v1.IncidentService.CheckLocation:input_type -> redgo.v1.CheckLocationRequest
+	9,  // 12: redgo.v1.IncidentService.StreamLocation:input_type -> redgo.v1.CheckLocationRequest
+	0,  // 13: redgo.v1.IncidentService.CreateIncident:output_type -> redgo.v1.Incident
+	0,  // 14: redgo.v1.IncidentService.GetIncident:output_type -> redgo.v1.Incident
+	4,  // 15: redgo.v1.IncidentService.ListIncidents:output_type -> redgo.v1.ListIncidentsResponse
+	0,  // 16: redgo.v1.IncidentService.UpdateIncident:output_type -> redgo.v1.Incident
+	13, // 17: redgo.v1.IncidentService.DeleteIncident:output_type -> google.protobuf.Empty
+	8,  // 18: redgo.v1.IncidentService.GetStats:output_type -> redgo.v1.GetStatsResponse
+	11, // 19: redgo.v1.IncidentService.CheckLocation:output_type -> redgo.v1.CheckLocationResponse
+	11, // 20: redgo.v1.IncidentService.StreamLocation:output_type -> redgo.v1.CheckLocationResponse
+	13, // [13:21] is the sub-list for method output_type
+	5,  // [5:13] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_redgo_v1_incident_proto_init() }
+func file_redgo_v1_incident_proto_init() {
+	if File_redgo_v1_incident_proto != nil {
+		return
+	}
+	file_redgo_v1_incident_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_redgo_v1_incident_proto_rawDesc), len(file_redgo_v1_incident_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_redgo_v1_incident_proto_goTypes,
+		DependencyIndexes: file_redgo_v1_incident_proto_depIdxs,
+		MessageInfos:      file_redgo_v1_incident_proto_msgTypes,
+	}.Build()
+	File_redgo_v1_incident_proto = out.File
+	file_redgo_v1_incident_proto_goTypes = nil
+	file_redgo_v1_incident_proto_depIdxs = nil
+}
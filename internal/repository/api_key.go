@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) domain.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// apiKeyRow — строка таблицы: scopes в Postgres хранятся массивом TEXT[]
+type apiKeyRow struct {
+	ID         int            `db:"id"`
+	TenantID   string         `db:"tenant_id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+func (r apiKeyRow) toDomain() domain.APIKey {
+	scopes := make([]domain.Scope, 0, len(r.Scopes))
+	for _, s := range r.Scopes {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return domain.APIKey{
+		ID:         r.ID,
+		TenantID:   r.TenantID,
+		Name:       r.Name,
+		Prefix:     r.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  r.ExpiresAt,
+		RevokedAt:  r.RevokedAt,
+		LastUsedAt: r.LastUsedAt,
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+const apiKeyColumns = `id, tenant_id, name, prefix, scopes, expires_at, revoked_at, last_used_at, created_at`
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey, hash string) error {
+	scopes := make(pq.StringArray, 0, len(key.Scopes))
+	for _, s := range key.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	query := `
+		INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, key.TenantID, key.Name, key.Prefix, hash, scopes, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+	return wrapDBError(err)
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	var row apiKeyRow
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	err := r.db.GetContext(ctx, &row, query, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NewUnauthorizedError("invalid api key")
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	key := row.toDomain()
+	return &key, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context, tenantID string) ([]domain.APIKey, error) {
+	var rows []apiKeyRow
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE ($1 = '' OR tenant_id = $1) ORDER BY id`
+
+	if err := r.db.SelectContext(ctx, &rows, query, tenantID); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	keys := make([]domain.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toDomain())
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int, tenantID string) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 AND ($2 = '' OR tenant_id = $2)`
+
+	result, err := r.db.ExecContext(ctx, query, id, tenantID)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return domain.NewNotFoundError(domain.CodeNotFound, "api key not found")
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id)
+	return wrapDBError(err)
+}
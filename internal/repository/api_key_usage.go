@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// apiKeyUsageTTL — сколько дней хранить дневные счетчики
+const apiKeyUsageTTL = 30 * 24 * time.Hour
+
+type apiKeyUsageRepository struct {
+	redis *redis.Client
+}
+
+func NewAPIKeyUsageRepository(redis *redis.Client) domain.APIKeyUsageRepository {
+	return &apiKeyUsageRepository{redis: redis}
+}
+
+// usageKey — hash на ключ и день, поле — маршрут
+func usageKey(principalID string, day time.Time) string {
+	return "api_keys:usage:" + principalID + ":" + day.UTC().Format(time.DateOnly)
+}
+
+func (r *apiKeyUsageRepository) Incr(ctx context.Context, principalID, route string) error {
+	key := usageKey(principalID, time.Now())
+
+	pipe := r.redis.Pipeline()
+	pipe.HIncrBy(ctx, key, route, 1)
+	pipe.Expire(ctx, key, apiKeyUsageTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *apiKeyUsageRepository) Get(ctx context.Context, principalID string, days int) (map[string]map[string]int64, error) {
+	now := time.Now()
+	pipe := r.redis.Pipeline()
+	cmds := make(map[string]*redis.MapStringStringCmd, days)
+
+	for i := 0; i < days; i++ {
+		day := now.AddDate(0, 0, -i)
+		cmds[day.UTC().Format(time.DateOnly)] = pipe.HGetAll(ctx, usageKey(principalID, day))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, domain.NewUnavailableError("usage storage unavailable", err)
+	}
+
+	usage := make(map[string]map[string]int64)
+	for day, cmd := range cmds {
+		routes := make(map[string]int64)
+		for route, v := range cmd.Val() {
+			n, _ := strconv.ParseInt(v, 10, 64)
+			routes[route] = n
+		}
+		if len(routes) > 0 {
+			usage[day] = routes
+		}
+	}
+
+	return usage, nil
+}
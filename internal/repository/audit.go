@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) domain.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+type auditRow struct {
+	ID         int64     `db:"id"`
+	IncidentID int       `db:"incident_id"`
+	Action     string    `db:"action"`
+	ActorID    string    `db:"actor_id"`
+	ActorName  string    `db:"actor_name"`
+	ActorKind  string    `db:"actor_kind"`
+	Incident   []byte    `db:"incident"`
+	At         time.Time `db:"created_at"`
+}
+
+func (r *auditRepository) Save(ctx context.Context, rec domain.AuditRecord) error {
+	snapshot, err := json.Marshal(rec.Incident)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO incident_audit (tenant_id, incident_id, action, actor_id, actor_name, actor_kind, incident)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = r.db.ExecContext(ctx, query, domain.TenantFrom(ctx),
+		rec.IncidentID, rec.Action, rec.ActorID, rec.ActorName, rec.ActorKind, snapshot)
+	return wrapDBError(err)
+}
+
+func (r *auditRepository) ListByIncident(ctx context.Context, incidentID int, limit int) ([]domain.AuditRecord, error) {
+	var rows []auditRow
+	query := `
+		SELECT id, incident_id, action, actor_id, actor_name, actor_kind, incident, created_at
+		FROM incident_audit
+		WHERE tenant_id = $3 AND incident_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+	if err := r.db.SelectContext(ctx, &rows, query, incidentID, limit, domain.TenantFrom(ctx)); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	records := make([]domain.AuditRecord, 0, len(rows))
+	for _, row := range rows {
+		rec := domain.AuditRecord{
+			ID:         row.ID,
+			IncidentID: row.IncidentID,
+			Action:     domain.IncidentEventType(row.Action),
+			ActorID:    row.ActorID,
+			ActorName:  row.ActorName,
+			ActorKind:  row.ActorKind,
+			At:         row.At,
+		}
+		if err := json.Unmarshal(row.Incident, &rec.Incident); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
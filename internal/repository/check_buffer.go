@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/metrics"
+)
+
+type CheckBufferConfig struct {
+	FlushSize     int           // проверок в одном INSERT и порог досрочного сброса
+	FlushInterval time.Duration // как часто сбрасывать неполный буфер
+	Limit         int           // больше проверок в памяти не держим, дальше пишем синхронно
+}
+
+// CheckBuffer — write-behind для проверок местоположения. SaveCheck и SaveChecks кладут проверки в память
+// и сразу возвращаются, Run сбрасывает их пачками multi-row INSERT'ом. Остальные методы идут в обернутый репозиторий.
+// created_at проставляет база при сбросе, поэтому он отстает от проверки не больше чем на FlushInterval
+type CheckBuffer struct {
+	domain.IncidentRepository
+	cfg CheckBufferConfig
+
+	mu      sync.Mutex
+	pending []domain.LocationCheck
+	closed  bool          // после Close буфер не копит: запоздавшая проверка пишется сразу
+	full    chan struct{} // набралось FlushSize, сбросить не дожидаясь интервала
+
+	flushMu sync.Mutex // один сброс за раз, чтобы пачки не обгоняли друг друга
+}
+
+func NewCheckBuffer(repo domain.IncidentRepository, cfg CheckBufferConfig) *CheckBuffer {
+	return &CheckBuffer{
+		IncidentRepository: repo,
+		cfg:                cfg,
+		full:               make(chan struct{}, 1),
+	}
+}
+
+func (b *CheckBuffer) SaveCheck(ctx context.Context, check domain.LocationCheck) error {
+	return b.SaveChecks(ctx, []domain.LocationCheck{check})
+}
+
+func (b *CheckBuffer) SaveChecks(ctx context.Context, checks []domain.LocationCheck) error {
+	tenantID := domain.TenantFrom(ctx)
+
+	b.mu.Lock()
+	if b.closed || len(b.pending)+len(checks) > b.cfg.Limit {
+		b.mu.Unlock()
+		// Буфер полон или уже дописан при остановке — пишем сами: медленнее, но без потерь,
+		// и недоступный Postgres снова виден вызывающему
+		return b.IncidentRepository.SaveChecks(ctx, checks)
+	}
+	// Тенант берем сейчас: при сбросе контекста запроса уже нет
+	for _, check := range checks {
+		check.TenantID = tenantID
+		b.pending = append(b.pending, check)
+	}
+	n := len(b.pending)
+	b.mu.Unlock()
+
+	metrics.CheckBufferPending.Set(float64(n))
+	if n >= b.cfg.FlushSize {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Run сбрасывает буфер раз в FlushInterval или по заполнении до отмены ctx. Остаток дописывает Close
+func (b *CheckBuffer) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.full:
+		}
+		// Начатый сброс доводим до конца, даже если ctx отменили посередине
+		if err := b.flush(context.WithoutCancel(ctx)); err != nil {
+			slog.WarnContext(ctx, "failed to flush location checks, will retry", logging.Err(err))
+		}
+	}
+}
+
+// Close дописывает все, что осталось в буфере. Вызывается при остановке после серверов, до закрытия базы.
+// Проверки, пришедшие после Close, пишутся синхронно
+func (b *CheckBuffer) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	err := b.flush(ctx)
+	if err != nil {
+		b.mu.Lock()
+		lost := len(b.pending)
+		b.mu.Unlock()
+		metrics.CheckBufferDropped.Add(float64(lost))
+		slog.ErrorContext(ctx, "location checks lost on shutdown", "count", lost, logging.Err(err))
+	}
+	return err
+}
+
+// flush сбрасывает буфер пачками по FlushSize, пока он не опустеет. Недоступная база — пачка возвращается
+// в начало буфера до следующей попытки; любая другая ошибка повторится и на ретрае, такую пачку выбрасываем
+func (b *CheckBuffer) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	for {
+		b.mu.Lock()
+		n := min(len(b.pending), b.cfg.FlushSize)
+		if n == 0 {
+			b.mu.Unlock()
+			metrics.CheckBufferPending.Set(0)
+			return nil
+		}
+		batch := make([]domain.LocationCheck, n)
+		copy(batch, b.pending)
+		b.pending = append(b.pending[:0], b.pending[n:]...)
+		b.mu.Unlock()
+
+		unwritten, err := b.write(ctx, batch)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, domain.ErrUnavailable) || ctx.Err() != nil {
+			b.mu.Lock()
+			b.pending = append(unwritten, b.pending...)
+			metrics.CheckBufferPending.Set(float64(len(b.pending)))
+			b.mu.Unlock()
+			return err
+		}
+		metrics.CheckBufferDropped.Add(float64(len(unwritten)))
+		slog.ErrorContext(ctx, "location checks dropped", "count", len(unwritten), logging.Err(err))
+	}
+}
+
+// write пишет пачку по тенантам: SaveChecks берет тенант из контекста. Возвращает то, что записать не удалось
+func (b *CheckBuffer) write(ctx context.Context, batch []domain.LocationCheck) ([]domain.LocationCheck, error) {
+	var tenants []string
+	byTenant := make(map[string][]domain.LocationCheck)
+	for _, check := range batch {
+		if _, ok := byTenant[check.TenantID]; !ok {
+			tenants = append(tenants, check.TenantID)
+		}
+		byTenant[check.TenantID] = append(byTenant[check.TenantID], check)
+	}
+
+	for i, tenantID := range tenants {
+		if err := b.IncidentRepository.SaveChecks(domain.WithTenant(ctx, tenantID), byTenant[tenantID]); err != nil {
+			var unwritten []domain.LocationCheck
+			for _, rest := range tenants[i:] {
+				unwritten = append(unwritten, byTenant[rest]...)
+			}
+			return unwritten, err
+		}
+	}
+	return nil, nil
+}
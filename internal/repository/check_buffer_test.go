@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// fakeCheckRepo запоминает записанные пачки. fail — ошибки для следующих вызовов SaveChecks по порядку
+type fakeCheckRepo struct {
+	domain.IncidentRepository
+
+	mu      sync.Mutex
+	batches [][]domain.LocationCheck
+	fail    []error
+	written chan struct{}
+}
+
+func newFakeCheckRepo() *fakeCheckRepo {
+	return &fakeCheckRepo{written: make(chan struct{}, 100)}
+}
+
+func (r *fakeCheckRepo) SaveChecks(ctx context.Context, checks []domain.LocationCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.fail) > 0 {
+		err := r.fail[0]
+		r.fail = r.fail[1:]
+		if err != nil {
+			return err
+		}
+	}
+
+	batch := make([]domain.LocationCheck, len(checks))
+	for i, c := range checks {
+		// Как и настоящий репозиторий, тенант берем из контекста
+		c.TenantID = domain.TenantFrom(ctx)
+		batch[i] = c
+	}
+	r.batches = append(r.batches, batch)
+	r.written <- struct{}{}
+	return nil
+}
+
+func (r *fakeCheckRepo) failNext(errs ...error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fail = append(r.fail, errs...)
+}
+
+func (r *fakeCheckRepo) snapshot() [][]domain.LocationCheck {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]domain.LocationCheck(nil), r.batches...)
+}
+
+func (r *fakeCheckRepo) userIDs() []int {
+	var ids []int
+	for _, batch := range r.snapshot() {
+		for _, c := range batch {
+			ids = append(ids, c.UserID)
+		}
+	}
+	return ids
+}
+
+func (r *fakeCheckRepo) waitWrite(t *testing.T) {
+	t.Helper()
+	select {
+	case <-r.written:
+	case <-time.After(2 * time.Second):
+		t.Fatal("buffer was not flushed")
+	}
+}
+
+func checks(userIDs ...int) []domain.LocationCheck {
+	out := make([]domain.LocationCheck, 0, len(userIDs))
+	for _, id := range userIDs {
+		out = append(out, domain.LocationCheck{UserID: id, MapID: domain.DefaultMapID, X: float64(id), Y: float64(id)})
+	}
+	return out
+}
+
+func (b *CheckBuffer) pendingLen() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+func runBuffer(t *testing.T, b *CheckBuffer) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestCheckBufferFlushesWhenFull(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 3, FlushInterval: time.Hour, Limit: 100})
+	runBuffer(t, b)
+
+	ctx := context.Background()
+	if err := b.SaveChecks(ctx, checks(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.snapshot(); len(got) != 0 {
+		t.Fatalf("flushed before FlushSize: %v", got)
+	}
+
+	if err := b.SaveCheck(ctx, checks(3)[0]); err != nil {
+		t.Fatal(err)
+	}
+	repo.waitWrite(t)
+
+	if got := repo.userIDs(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("written %v, want [1 2 3]", got)
+	}
+	if n := b.pendingLen(); n != 0 {
+		t.Errorf("%d checks left in buffer", n)
+	}
+}
+
+func TestCheckBufferFlushesOnInterval(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 100, FlushInterval: 10 * time.Millisecond, Limit: 1000})
+	runBuffer(t, b)
+
+	if err := b.SaveCheck(context.Background(), checks(1)[0]); err != nil {
+		t.Fatal(err)
+	}
+	repo.waitWrite(t)
+
+	if got := repo.userIDs(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("written %v, want [1]", got)
+	}
+}
+
+func TestCheckBufferSplitsIntoBatches(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 2, FlushInterval: time.Hour, Limit: 100})
+
+	if err := b.SaveChecks(context.Background(), checks(1, 2, 3, 4, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, batch := range repo.snapshot() {
+		sizes = append(sizes, len(batch))
+	}
+	if !reflect.DeepEqual(sizes, []int{2, 2, 1}) {
+		t.Errorf("batch sizes %v, want [2 2 1]", sizes)
+	}
+}
+
+func TestCheckBufferRequeuesWhenUnavailable(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 2, FlushInterval: time.Hour, Limit: 100})
+	ctx := context.Background()
+
+	if err := b.SaveChecks(ctx, checks(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Первая пачка уходит, вторая упирается в недоступную базу и возвращается в буфер
+	repo.failNext(nil, domain.NewUnavailableError("database unavailable", errors.New("dial tcp")))
+	if err := b.flush(ctx); !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("flush: %v, want unavailable", err)
+	}
+	if n := b.pendingLen(); n != 1 {
+		t.Fatalf("%d checks in buffer after failed flush, want 1", n)
+	}
+
+	// Новые проверки встают за возвращенными
+	if err := b.SaveCheck(ctx, checks(4)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.userIDs(); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+		t.Errorf("written %v, want [1 2 3 4]", got)
+	}
+}
+
+func TestCheckBufferDropsOnPermanentError(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 2, FlushInterval: time.Hour, Limit: 100})
+	ctx := context.Background()
+
+	if err := b.SaveChecks(ctx, checks(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ошибка данных повторится и на ретрае: пачку выбрасываем, остальное пишем
+	repo.failNext(errors.New("pq: value too long"))
+	if err := b.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.userIDs(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("written %v, want [3]", got)
+	}
+	if n := b.pendingLen(); n != 0 {
+		t.Errorf("%d checks left in buffer", n)
+	}
+}
+
+func TestCheckBufferWritesThroughWhenOverLimit(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 10, FlushInterval: time.Hour, Limit: 2})
+	ctx := domain.WithTenant(context.Background(), "acme")
+
+	if err := b.SaveChecks(ctx, checks(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SaveCheck(ctx, checks(3)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.userIDs(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("written through %v, want [3]", got)
+	}
+
+	// Недоступная база при переполнении видна вызывающему
+	repo.failNext(domain.NewUnavailableError("database unavailable", nil))
+	if err := b.SaveCheck(ctx, checks(4)[0]); !errors.Is(err, domain.ErrUnavailable) {
+		t.Errorf("SaveCheck over limit: %v, want unavailable", err)
+	}
+}
+
+func TestCheckBufferCloseDrainsByTenant(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 100, FlushInterval: time.Hour, Limit: 1000})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Run(ctx)
+		close(done)
+	}()
+
+	acme := domain.WithTenant(context.Background(), "acme")
+	other := domain.WithTenant(context.Background(), "other")
+	for _, save := range []struct {
+		ctx    context.Context
+		checks []domain.LocationCheck
+	}{
+		{acme, checks(1, 2)},
+		{other, checks(3)},
+		{acme, checks(4)},
+	} {
+		if err := b.SaveChecks(save.ctx, save.checks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Остановка: сначала гасим Run, потом Close дописывает остаток
+	cancel()
+	<-done
+	if got := repo.snapshot(); len(got) != 0 {
+		t.Fatalf("flushed before Close: %v", got)
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(map[string][]int)
+	for _, batch := range repo.snapshot() {
+		for _, c := range batch {
+			written[c.TenantID] = append(written[c.TenantID], c.UserID)
+		}
+	}
+	want := map[string][]int{"acme": {1, 2, 4}, "other": {3}}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("written %v, want %v", written, want)
+	}
+	if n := b.pendingLen(); n != 0 {
+		t.Errorf("%d checks left after Close", n)
+	}
+}
+
+func TestCheckBufferCloseReportsLoss(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 100, FlushInterval: time.Hour, Limit: 1000})
+
+	if err := b.SaveChecks(context.Background(), checks(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	repo.failNext(domain.NewUnavailableError("database unavailable", nil))
+	if err := b.Close(context.Background()); !errors.Is(err, domain.ErrUnavailable) {
+		t.Errorf("Close: %v, want unavailable", err)
+	}
+}
+
+func TestCheckBufferWritesThroughAfterClose(t *testing.T) {
+	repo := newFakeCheckRepo()
+	b := NewCheckBuffer(repo, CheckBufferConfig{FlushSize: 100, FlushInterval: time.Hour, Limit: 1000})
+	ctx := domain.WithTenant(context.Background(), "acme")
+
+	if err := b.SaveChecks(ctx, checks(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запоздавший стрим: проверка не должна остаться в памяти, которую уже никто не сбросит
+	if err := b.SaveCheck(ctx, checks(2)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.userIDs(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("written %v, want [1 2]", got)
+	}
+	if n := b.pendingLen(); n != 0 {
+		t.Errorf("%d checks buffered after Close", n)
+	}
+}
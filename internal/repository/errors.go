@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/lib/pq"
+)
+
+// wrapDBError переводит ошибки драйвера Postgres в ошибки предметной области.
+// sql.ErrNoRows сюда не попадает — что именно не найдено, знает вызывающий метод
+func wrapDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Name() == "unique_violation":
+			return domain.NewConflictError(domain.CodeConflict, "resource already exists", err)
+		// Единственные внешние ключи ведут на tenants
+		case pqErr.Code.Name() == "foreign_key_violation":
+			return domain.NewValidationError(domain.CodeInvalidTenant, "tenant does not exist")
+		// 08 — проблемы соединения, 53 — нехватка ресурсов. Из класса 57 только остановка и рестарт сервера:
+		// 57014 query_canceled — это таймаут или отмена запроса, а не недоступная база
+		case pqErr.Code.Class() == "08", pqErr.Code.Class() == "53",
+			pqErr.Code == "57P01", pqErr.Code == "57P02", pqErr.Code == "57P03":
+			return domain.NewUnavailableError("database unavailable", err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) {
+		return domain.NewUnavailableError("database unavailable", err)
+	}
+
+	return err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/lib/pq"
+)
+
+func TestWrapDBError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error // nil — ошибка возвращается как есть
+	}{
+		{"unique violation", &pq.Error{Code: "23505"}, domain.ErrConflict},
+		{"foreign key violation", &pq.Error{Code: "23503"}, domain.ErrValidation},
+		{"connection failure", &pq.Error{Code: "08006"}, domain.ErrUnavailable},
+		{"too many connections", &pq.Error{Code: "53300"}, domain.ErrUnavailable},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, domain.ErrUnavailable},
+		{"crash shutdown", &pq.Error{Code: "57P02"}, domain.ErrUnavailable},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, domain.ErrUnavailable},
+		{"query canceled", &pq.Error{Code: "57014"}, nil},
+		{"syntax error", &pq.Error{Code: "42601"}, nil},
+		{"bad connection", fmt.Errorf("exec: %w", driver.ErrBadConn), domain.ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapDBError(tt.err)
+			if tt.want == nil {
+				if got != tt.err {
+					t.Errorf("wrapDBError(%v) = %v, want it unchanged", tt.err, got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("wrapDBError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+type incidentEventRepository struct {
+	redis *redis.Client
+}
+
+func NewIncidentEventRepository(redis *redis.Client) domain.EventRepository {
+	return &incidentEventRepository{redis: redis}
+}
+
+func (r *incidentEventRepository) Publish(ctx context.Context, ev domain.IncidentEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	tenantID := domain.TenantFrom(ctx)
+
+	// 1. Пишем в Stream — он выдает монотонный ID и хранит хвост для переподключений
+	id, err := r.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: domain.TenantKey(tenantID, domain.IncidentEventsStreamKey),
+		MaxLen: domain.IncidentEventsMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": data},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	// 2. Рассылаем уже с ID, чтобы подписчики могли отбросить дубли после replay
+	ev.ID = id
+	data, err = json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	return r.redis.Publish(ctx, domain.TenantKey(tenantID, domain.IncidentEventsChannelKey), data).Err()
+}
+
+func (r *incidentEventRepository) Since(ctx context.Context, lastID string) ([]domain.IncidentEvent, error) {
+	// "(" — исключающая граница, само событие lastID клиент уже видел
+	msgs, err := r.redis.XRange(ctx, domain.TenantKey(domain.TenantFrom(ctx), domain.IncidentEventsStreamKey), "("+lastID, "+").Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "Invalid stream ID") {
+			return nil, domain.NewValidationError(domain.CodeValidation, "invalid Last-Event-ID",
+				domain.FieldError{Field: "Last-Event-ID", Message: "must be an event id previously sent by the server"})
+		}
+		return nil, domain.NewUnavailableError("event history unavailable", err)
+	}
+
+	events := make([]domain.IncidentEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		raw, ok := msg.Values["event"].(string)
+		if !ok {
+			continue
+		}
+
+		var ev domain.IncidentEvent
+		if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+			slog.WarnContext(ctx, "broken event in stream", "event_id", msg.ID, logging.Err(err))
+			continue
+		}
+		ev.ID = msg.ID
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+func (r *incidentEventRepository) Subscribe(ctx context.Context) (<-chan domain.IncidentEvent, error) {
+	pubsub := r.redis.Subscribe(ctx, domain.TenantKey(domain.TenantFrom(ctx), domain.IncidentEventsChannelKey))
+
+	// Дожидаемся подтверждения подписки, иначе события между Subscribe и replay могут потеряться
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, domain.NewUnavailableError("event stream unavailable", err)
+	}
+
+	out := make(chan domain.IncidentEvent, 64)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				var ev domain.IncidentEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+					slog.WarnContext(ctx, "broken event in channel", logging.Err(err))
+					continue
+				}
+
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
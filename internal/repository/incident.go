@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
 	"github.com/ArtemChadaev/RedGo/internal/domain"
@@ -18,38 +19,55 @@ func NewIncidentRepository(db *sqlx.DB) domain.IncidentRepository {
 
 func (r *incidentRepository) Create(ctx context.Context, inc *domain.Incident) error {
 	query := `
-		INSERT INTO incidents (description, x, y, status)
-		VALUES (:description, :x, :y, :status)
-		RETURNING id
+		INSERT INTO incidents (tenant_id, description, x, y, status, map_id)
+		VALUES (:tenant_id, :description, :x, :y, :status, :map_id)
+		RETURNING id, version
 	`
-	rows, err := r.db.NamedQueryContext(ctx, query, inc)
+	// tenant_id не поле инцидента: клиент не может выбрать чужого тенанта
+	args := map[string]interface{}{
+		"tenant_id":   domain.TenantFrom(ctx),
+		"description": inc.Description,
+		"x":           inc.X,
+		"y":           inc.Y,
+		"status":      inc.Status,
+		"map_id":      inc.MapID,
+	}
+	rows, err := r.db.NamedQueryContext(ctx, query, args)
 	if err != nil {
-		return err
+		return wrapDBError(err)
 	}
 	defer rows.Close()
 
 	if rows.Next() {
-		if err := rows.Scan(&inc.ID); err != nil {
-			return err
+		if err := rows.Scan(&inc.ID, &inc.Version); err != nil {
+			return wrapDBError(err)
 		}
 	}
 
-	return rows.Err()
+	return wrapDBError(rows.Err())
 }
 
-func (r *incidentRepository) GetAll(ctx context.Context, limit, offset int) ([]domain.Incident, error) {
+func (r *incidentRepository) GetAll(ctx context.Context, filter domain.IncidentFilter, limit, offset int) ([]domain.Incident, error) {
 	incidents := make([]domain.Incident, 0, limit)
 
+	// Пустой фильтр отключает условие, чтобы не собирать SQL динамически
 	query := `
-		SELECT id, description, x, y, status 
+		SELECT id, description, x, y, status, map_id, version, deleted_at 
 		FROM incidents 
+		WHERE tenant_id = $5
+		  AND ($3 = '' OR map_id = $3)
+		  AND (
+		      ($4 = '' AND deleted_at IS NULL)
+		      OR $4 = 'include'
+		      OR ($4 = 'only' AND deleted_at IS NOT NULL)
+		  )
 		ORDER BY id DESC 
 		LIMIT $1 OFFSET $2
 	`
 
-	err := r.db.SelectContext(ctx, &incidents, query, limit, offset)
+	err := r.db.SelectContext(ctx, &incidents, query, limit, offset, filter.MapID, filter.Deleted, domain.TenantFrom(ctx))
 	if err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 
 	return incidents, nil
@@ -57,49 +75,130 @@ func (r *incidentRepository) GetAll(ctx context.Context, limit, offset int) ([]d
 
 func (r *incidentRepository) GetByID(ctx context.Context, id int) (*domain.Incident, error) {
 	var incident domain.Incident
-	query := `SELECT id, description, x, y, status FROM incidents WHERE id = $1`
+	// Удаленные (soft delete) для чтения по ID не существуют, их видно только в списке с deleted=include|only
+	query := `SELECT id, description, x, y, status, map_id, version, deleted_at FROM incidents WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
 
-	err := r.db.GetContext(ctx, &incident, query, id)
+	err := r.db.GetContext(ctx, &incident, query, id, domain.TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrIncidentNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 
 	return &incident, nil
 }
 
-func (r *incidentRepository) Update(ctx context.Context, id int, input domain.UpdateIncidentInput) error {
-	// В PostgreSQL COALESCE идеально подходит для Partial Update
+func (r *incidentRepository) Update(ctx context.Context, id int, input domain.UpdateIncidentInput, version int) (*domain.Incident, error) {
+	// В PostgreSQL COALESCE идеально подходит для Partial Update.
+	// version = 0 отключает проверку версии (запрос без If-Match)
 	query := `
         UPDATE incidents 
         SET 
             x = COALESCE($1, x), 
             y = COALESCE($2, y), 
             description = COALESCE($3, description),
-            status = COALESCE($4, status)
-        WHERE id = $5
+            status = COALESCE($4, status),
+            map_id = COALESCE($5, map_id),
+            version = version + 1
+        WHERE id = $6 AND tenant_id = $8 AND deleted_at IS NULL AND ($7 = 0 OR version = $7)
+        RETURNING id, description, x, y, status, map_id, version, deleted_at
     `
 
 	// Передаем указатели напрямую.
 	// Если в структуре поле nil, драйвер sql/pq отправит в базу NULL.
-	result, err := r.db.ExecContext(ctx, query, input.X, input.Y, input.Description, input.Status, id)
+	var incident domain.Incident
+	err := r.db.GetContext(ctx, &incident, query, input.X, input.Y, input.Description, input.Status, input.MapID, id, version, domain.TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Ни одна строка не обновлена: либо нет записи, либо версия устарела
+		return nil, r.missingOrStale(ctx, id)
+	}
 	if err != nil {
-		return err
+		return nil, wrapDBError(err)
 	}
 
-	// Проверяем, была ли обновлена хотя бы одна строка
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return errors.New("incident not found")
+	return &incident, nil
+}
+
+func (r *incidentRepository) Delete(ctx context.Context, id int, version int) (*domain.Incident, error) {
+	query := `
+        UPDATE incidents 
+        SET deleted_at = NOW(), version = version + 1 
+        WHERE id = $1 AND tenant_id = $3 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+        RETURNING id, description, x, y, status, map_id, version, deleted_at
+    `
+
+	var incident domain.Incident
+	err := r.db.GetContext(ctx, &incident, query, id, version, domain.TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, r.missingOrStale(ctx, id)
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
 	}
 
-	return nil
+	return &incident, nil
+}
+
+func (r *incidentRepository) Restore(ctx context.Context, id int) (*domain.Incident, error) {
+	query := `
+        UPDATE incidents 
+        SET deleted_at = NULL, version = version + 1 
+        WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL
+        RETURNING id, description, x, y, status, map_id, version, deleted_at
+    `
+
+	var incident domain.Incident
+	err := r.db.GetContext(ctx, &incident, query, id, domain.TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Либо записи нет вовсе, либо она и не была удалена
+		var exists bool
+		if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM incidents WHERE id = $1 AND tenant_id = $2)`, id, domain.TenantFrom(ctx)); err != nil {
+			return nil, wrapDBError(err)
+		}
+		if exists {
+			return nil, domain.ErrIncidentNotDeleted
+		}
+		return nil, domain.ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return &incident, nil
 }
 
-func (r *incidentRepository) Delete(ctx context.Context, id int) error {
-	query := `UPDATE incidents SET status = $1 WHERE id = $2`
+func (r *incidentRepository) Purge(ctx context.Context, id int) (*domain.Incident, error) {
+	query := `
+        DELETE FROM incidents 
+        WHERE id = $1 AND tenant_id = $2
+        RETURNING id, description, x, y, status, map_id, version, deleted_at
+    `
+
+	var incident domain.Incident
+	err := r.db.GetContext(ctx, &incident, query, id, domain.TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
 
-	_, err := r.db.ExecContext(ctx, query, domain.StatusInactive, id)
-	return err
+	return &incident, nil
+}
+
+// missingOrStale объясняет, почему условный UPDATE не затронул строк
+func (r *incidentRepository) missingOrStale(ctx context.Context, id int) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM incidents WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL)`
+	if err := r.db.GetContext(ctx, &exists, query, id, domain.TenantFrom(ctx)); err != nil {
+		return wrapDBError(err)
+	}
+
+	if exists {
+		return domain.ErrVersionMismatch
+	}
+	return domain.ErrIncidentNotFound
 }
 
 func (r *incidentRepository) GetStats(ctx context.Context, windowMinutes int) (int, error) {
@@ -107,43 +206,66 @@ func (r *incidentRepository) GetStats(ctx context.Context, windowMinutes int) (i
 	query := `
        SELECT COUNT(DISTINCT user_id) 
        FROM location_checks 
-       WHERE created_at >= NOW() - INTERVAL '1 minute' * $1
+       WHERE tenant_id = $2 AND created_at >= NOW() - INTERVAL '1 minute' * $1
     `
 
-	err := r.db.GetContext(ctx, &count, query, windowMinutes)
-	return count, err
+	err := r.db.GetContext(ctx, &count, query, windowMinutes, domain.TenantFrom(ctx))
+	return count, wrapDBError(err)
+}
+
+func (r *incidentRepository) SaveCheck(ctx context.Context, check domain.LocationCheck) error {
+	query := `INSERT INTO location_checks (tenant_id, user_id, map_id, x, y) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query, domain.TenantFrom(ctx), check.UserID, check.MapID, check.X, check.Y)
+	return wrapDBError(err)
 }
 
-func (r *incidentRepository) SaveCheck(ctx context.Context, userID int, x, y float64) error {
-	query := `INSERT INTO location_checks (user_id, x, y) VALUES ($1, $2, $3)`
-	_, err := r.db.ExecContext(ctx, query, userID, x, y)
-	return err
+// saveChecksChunk — строк на один INSERT. 5 параметров на строку, лимит Postgres 65535
+const saveChecksChunk = 1000
+
+func (r *incidentRepository) SaveChecks(ctx context.Context, checks []domain.LocationCheck) error {
+	query := `INSERT INTO location_checks (tenant_id, user_id, map_id, x, y) VALUES (:tenant_id, :user_id, :map_id, :x, :y)`
+
+	tenantID := domain.TenantFrom(ctx)
+	for i := range checks {
+		checks[i].TenantID = tenantID
+	}
+
+	for start := 0; start < len(checks); start += saveChecksChunk {
+		end := min(start+saveChecksChunk, len(checks))
+
+		// sqlx разворачивает срез структур в один multi-row VALUES
+		if _, err := r.db.NamedExecContext(ctx, query, checks[start:end]); err != nil {
+			return wrapDBError(err)
+		}
+	}
+
+	return nil
 }
 
-func (r *incidentRepository) GetAllActive(ctx context.Context) ([]domain.Incident, error) {
+func (r *incidentRepository) GetAllActive(ctx context.Context, mapID string) ([]domain.Incident, error) {
 	query := `
-        SELECT id, x, y, status 
+        SELECT id, x, y, status, map_id 
         FROM incidents 
-        WHERE status = $1
+        WHERE tenant_id = $3 AND status = $1 AND map_id = $2 AND deleted_at IS NULL
     `
 
-	rows, err := r.db.QueryContext(ctx, query, domain.StatusActive)
+	rows, err := r.db.QueryContext(ctx, query, domain.StatusActive, mapID, domain.TenantFrom(ctx))
 	if err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 	defer rows.Close()
 
 	var incidents []domain.Incident
 	for rows.Next() {
 		var inc domain.Incident
-		if err := rows.Scan(&inc.ID, &inc.X, &inc.Y, &inc.Status); err != nil {
-			return nil, err
+		if err := rows.Scan(&inc.ID, &inc.X, &inc.Y, &inc.Status, &inc.MapID); err != nil {
+			return nil, wrapDBError(err)
 		}
 		incidents = append(incidents, inc)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 
 	return incidents, nil
@@ -151,5 +273,5 @@ func (r *incidentRepository) GetAllActive(ctx context.Context) ([]domain.Inciden
 
 func (r *incidentRepository) PingDB(ctx context.Context) error {
 	// PingContext проверяет, что соединение с базой данных всё еще активно
-	return r.db.PingContext(ctx)
+	return wrapDBError(r.db.PingContext(ctx))
 }
@@ -12,16 +12,23 @@ import (
 
 type incidentCasheRepository struct {
 	redis *redis.Client
+	ttl   time.Duration
 }
 
-func NewIncidentCasheRepository(redis *redis.Client) domain.IncidentCacheRepository {
-	return &incidentCasheRepository{redis: redis}
+func NewIncidentCasheRepository(redis *redis.Client, ttl time.Duration) domain.IncidentCacheRepository {
+	return &incidentCasheRepository{redis: redis, ttl: ttl}
 }
 
-const activeIncidentsKey = "incidents:active"
+// activeIncidentsKey — hash, где поле это map_id, а значение — JSON активных инцидентов карты.
+// Один ключ на все карты тенанта позволяет сбросить его кэш целиком одной командой DEL
+const activeIncidentsKey = "incidents:active:maps"
 
-func (r *incidentCasheRepository) GetActive(ctx context.Context) ([]domain.Incident, error) {
-	val, err := r.redis.Get(ctx, activeIncidentsKey).Result()
+func activeKey(ctx context.Context) string {
+	return domain.TenantKey(domain.TenantFrom(ctx), activeIncidentsKey)
+}
+
+func (r *incidentCasheRepository) GetActive(ctx context.Context, mapID string) ([]domain.Incident, error) {
+	val, err := r.redis.HGet(ctx, activeKey(ctx), mapID).Result()
 
 	if errors.Is(err, redis.Nil) {
 		return nil, nil
@@ -38,17 +45,28 @@ func (r *incidentCasheRepository) GetActive(ctx context.Context) ([]domain.Incid
 	return incidents, nil
 }
 
-func (r *incidentCasheRepository) SetActive(ctx context.Context, incidents []domain.Incident) error {
+func (r *incidentCasheRepository) SetActive(ctx context.Context, mapID string, incidents []domain.Incident) error {
+	// Пустой срез сохраняем как [], а не null, иначе карта без инцидентов
+	// будет выглядеть как промах кэша и каждый раз ходить в базу
+	if incidents == nil {
+		incidents = []domain.Incident{}
+	}
+
 	data, err := json.Marshal(incidents)
 	if err != nil {
 		return err
 	}
 
-	return r.redis.Set(ctx, activeIncidentsKey, data, 10*time.Minute).Err()
+	key := activeKey(ctx)
+	pipe := r.redis.TxPipeline()
+	pipe.HSet(ctx, key, mapID, data)
+	pipe.Expire(ctx, key, r.ttl)
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
 func (r *incidentCasheRepository) DeleteActive(ctx context.Context) error {
-	return r.redis.Del(ctx, activeIncidentsKey).Err()
+	return r.redis.Del(ctx, activeKey(ctx)).Err()
 }
 
 func (r *incidentCasheRepository) PingRedis(ctx context.Context) error {
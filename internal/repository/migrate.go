@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationLockID — ключ advisory lock: реплики, стартующие одновременно, мигрируют по очереди
+const migrationLockID int64 = 0x52656447 // "RedG"
+
+// Таблица версий в формате golang-migrate: базы, которые мигрировали образом migrate/migrate, подхватываются как есть
+const migrationsTable = "schema_migrations"
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration // по возрастанию версии
+}
+
+// NewMigrator читает пары up/down из files. Миграция без пары — ошибка: откатить ее было бы нечем
+func NewMigrator(db *sqlx.DB, files fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(files, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d: different names %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrator := &Migrator{db: db}
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down files are required", mig.Version, mig.Name)
+		}
+		migrator.migrations = append(migrator.migrations, *mig)
+	}
+	slices.SortFunc(migrator.migrations, func(a, b Migration) int { return int(a.Version - b.Version) })
+	return migrator, nil
+}
+
+// Up применяет все непримененные миграции, возвращает сколько применено
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sqlx.Conn, current int64) error {
+		if current == 0 {
+			if err := checkUnversioned(ctx, conn); err != nil {
+				return err
+			}
+		}
+		idx, err := m.position(current)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations[idx+1:] {
+			if err := m.apply(ctx, conn, mig, mig.Up, mig.Version); err != nil {
+				return err
+			}
+			slog.Info("migration applied", "version", mig.Version, "name", mig.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Baseline помечает базу без версии как уже мигрированную до version, не выполняя SQL.
+// Нужен для старых баз, созданных скриптами initdb из docker-compose: схема есть, а schema_migrations пуста
+func (m *Migrator) Baseline(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn, current int64) error {
+		if current != 0 {
+			return fmt.Errorf("baseline needs an unversioned database, current version is %d", current)
+		}
+		if version <= 0 {
+			return fmt.Errorf("baseline version must be positive, got %d", version)
+		}
+		idx, err := m.position(version)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, `INSERT INTO `+migrationsTable+` (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+		slog.Info("migration baseline set", "version", version, "name", m.migrations[idx].Name)
+		return nil
+	})
+}
+
+// checkUnversioned не дает накатывать миграции с нуля поверх существующей схемы: 000001 упадет на CREATE TABLE,
+// а причина — база создана без учета версий и ее сначала нужно разметить через Baseline
+func checkUnversioned(ctx context.Context, conn *sqlx.Conn) error {
+	var tables int
+	err := conn.GetContext(ctx, &tables, `
+		SELECT count(*) FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name <> '`+migrationsTable+`'`)
+	if err != nil {
+		return err
+	}
+	if tables > 0 {
+		return errors.New("database has tables but no migration version (created by initdb scripts?): " +
+			"run 'migrate baseline <version>' with the last migration already in the schema")
+	}
+	return nil
+}
+
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sqlx.Conn, current int64) error {
+		idx, err := m.position(current)
+		if err != nil {
+			return err
+		}
+		for ; idx >= 0 && reverted < steps; idx-- {
+			mig := m.migrations[idx]
+			if err := m.apply(ctx, conn, mig, mig.Down, m.versionBefore(idx)); err != nil {
+				return err
+			}
+			slog.Info("migration reverted", "version", mig.Version, "name", mig.Name)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status — текущая версия базы и список миграций бинарника с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) (int64, []MigrationStatus, error) {
+	var (
+		version int64
+		list    []MigrationStatus
+	)
+	err := m.withLock(ctx, func(_ *sqlx.Conn, current int64) error {
+		version = current
+		for _, mig := range m.migrations {
+			list = append(list, MigrationStatus{Version: mig.Version, Name: mig.Name, Applied: mig.Version <= current})
+		}
+		return nil
+	})
+	return version, list, err
+}
+
+// Verify проверяет на пустой базе, что каждая пара up/down обратима: после up и down схема
+// совпадает с исходной, и up после down снова проходит. Оставляет базу на последней версии
+func (m *Migrator) Verify(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn, current int64) error {
+		if current != 0 {
+			return fmt.Errorf("verify needs an empty database, current version is %d", current)
+		}
+
+		for i, mig := range m.migrations {
+			before, err := schemaSnapshot(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if err := m.apply(ctx, conn, mig, mig.Up, mig.Version); err != nil {
+				return err
+			}
+			if err := m.apply(ctx, conn, mig, mig.Down, m.versionBefore(i)); err != nil {
+				return err
+			}
+			after, err := schemaSnapshot(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if diff := snapshotDiff(before, after); diff != "" {
+				return fmt.Errorf("migration %d_%s: down does not restore the schema: %s", mig.Version, mig.Name, diff)
+			}
+			if err := m.apply(ctx, conn, mig, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: up after down: %w", mig.Version, mig.Name, err)
+			}
+			slog.Info("migration round-trips", "version", mig.Version, "name", mig.Name)
+		}
+		return nil
+	})
+}
+
+// withLock держит advisory lock на отдельном соединении: блокировка сессионная и живет, пока живет соединение
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn, current int64) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	// ctx мог уже отмениться, а снять блокировку нужно в любом случае
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		return err
+	}
+
+	var (
+		current int64
+		dirty   bool
+	)
+	err = conn.QueryRowxContext(ctx, `SELECT version, dirty FROM `+migrationsTable+` LIMIT 1`).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	// dirty оставляет только golang-migrate после упавшей миграции, наши идут в транзакции
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d: fix the schema by hand and set dirty = false", current)
+	}
+
+	return fn(conn, current)
+}
+
+// apply выполняет SQL миграции и записывает новую версию в одной транзакции: упавшая миграция не оставляет следов
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, mig Migration, query string, version int64) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+migrationsTable); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO `+migrationsTable+` (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// position — индекс примененной миграции с версией current, -1 для пустой базы
+func (m *Migrator) position(current int64) (int, error) {
+	if current == 0 {
+		return -1, nil
+	}
+	for i, mig := range m.migrations {
+		if mig.Version == current {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("database version %d is unknown to this binary: it was migrated by a newer release", current)
+}
+
+// versionBefore — версия базы после отката миграции idx
+func (m *Migrator) versionBefore(idx int) int64 {
+	if idx == 0 {
+		return 0
+	}
+	return m.migrations[idx-1].Version
+}
+
+// schemaSnapshot — колонки, индексы и ограничения текущей схемы без служебной таблицы версий
+func schemaSnapshot(ctx context.Context, conn *sqlx.Conn) ([]string, error) {
+	var rows []string
+	err := sqlx.SelectContext(ctx, conn, &rows, `
+		SELECT 'column ' || table_name || '.' || column_name || ' ' || data_type || ' ' || is_nullable || ' ' || coalesce(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name <> '`+migrationsTable+`'
+		UNION ALL
+		SELECT 'index ' || indexdef
+		FROM pg_indexes
+		WHERE schemaname = current_schema() AND tablename <> '`+migrationsTable+`'
+		UNION ALL
+		SELECT 'constraint ' || conrelid::regclass || ' ' || conname || ' ' || pg_get_constraintdef(oid)
+		FROM pg_constraint
+		WHERE connamespace = current_schema()::regnamespace AND conrelid <> '`+migrationsTable+`'::regclass
+		ORDER BY 1
+	`)
+	return rows, err
+}
+
+// snapshotDiff — что появилось и что пропало после отката, пусто — схемы совпадают
+func snapshotDiff(before, after []string) string {
+	var missing, extra []string
+	for _, s := range before {
+		if !slices.Contains(after, s) {
+			missing = append(missing, s)
+		}
+	}
+	for _, s := range after {
+		if !slices.Contains(before, s) {
+			extra = append(extra, s)
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("missing %v, left over %v", missing, extra)
+}
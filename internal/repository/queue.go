@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 
 	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,11 +18,37 @@ func NewIncidentQueueRepository(redis *redis.Client) domain.QueueRepository {
 	return &incidentQueueRepository{redis: redis}
 }
 
+// PushWebhookTask кладет задачу в очередь тенанта и отмечает тенанта в WebhookTenantsKey,
+// чтобы воркер знал, какие очереди разбирать
 func (r *incidentQueueRepository) PushWebhookTask(ctx context.Context, task domain.WebhookTask) error {
-	data, err := json.Marshal(task)
-	if err != nil {
-		return err
+	return r.PushWebhookTasks(ctx, []domain.WebhookTask{task})
+}
+
+func (r *incidentQueueRepository) PushWebhookTasks(ctx context.Context, tasks []domain.WebhookTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	tenantID := domain.TenantFrom(ctx)
+	key := domain.TenantKey(tenantID, domain.WebhookQueueKey)
+
+	traceContext := tracing.Inject(ctx)
+	requestID := logging.RequestIDFrom(ctx)
+
+	pipe := r.redis.Pipeline()
+	pipe.SAdd(ctx, domain.WebhookTenantsKey, tenantID)
+	for _, task := range tasks {
+		task.TenantID = tenantID
+		task.TraceContext = traceContext
+		task.RequestID = requestID
+		task.DeliveryID = logging.NewID()
+		data, err := json.Marshal(task)
+		if err != nil {
+			return err
+		}
+		pipe.RPush(ctx, key, data)
 	}
 
-	return r.redis.RPush(ctx, domain.WebhookQueueKey, data).Err()
+	_, err := pipe.Exec(ctx)
+	return err
 }
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript — скользящее окно на двух счетчиках: текущее окно и предыдущее с весом
+// оставшейся доли. Памяти O(1) на ключ, точность достаточная для защиты от флуда.
+// KEYS[1] — текущее окно, KEYS[2] — предыдущее; ARGV: limit, window_ms, elapsed_ms, cost
+// Возвращает {allowed, count, retry_after_ms}
+var slidingWindowScript = redis.NewScript(`
+    local limit = tonumber(ARGV[1])
+    local window = tonumber(ARGV[2])
+    local elapsed = tonumber(ARGV[3])
+    local cost = tonumber(ARGV[4])
+
+    local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+    local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+    local count = prev * (window - elapsed) / window + cur
+
+    if count + cost > limit then
+        local retry
+        if cur == 0 then
+            -- запрос больше всего лимита: ни одно окно его не вместит
+            retry = window * 2
+        elseif cur + cost > limit then
+            -- текущее окно уже переполнено само по себе: ждем следующее и пока оно «вытечет»
+            retry = (window - elapsed) + math.ceil(window * (cur + cost - limit) / cur)
+        else
+            -- место освободится, когда вес предыдущего окна упадет достаточно
+            retry = math.ceil((window - elapsed) - (limit - cost - cur) * window / prev)
+        end
+        return {0, math.floor(count), math.max(retry, 1)}
+    end
+
+    redis.call('INCRBY', KEYS[1], cost)
+    redis.call('PEXPIRE', KEYS[1], window * 2)
+    return {1, math.floor(count + cost), 0}
+`)
+
+type rateLimitRepository struct {
+	redis *redis.Client
+}
+
+func NewRateLimitRepository(redis *redis.Client) domain.RateLimitRepository {
+	return &rateLimitRepository{redis: redis}
+}
+
+func (r *rateLimitRepository) Allow(ctx context.Context, key string, limit int, window time.Duration, cost int) (domain.RateLimitResult, error) {
+	windowMs := window.Milliseconds()
+	nowMs := time.Now().UnixMilli()
+	idx := nowMs / windowMs
+	elapsed := nowMs % windowMs
+
+	res, err := slidingWindowScript.Run(ctx, r.redis,
+		[]string{key + ":" + strconv.FormatInt(idx, 10), key + ":" + strconv.FormatInt(idx-1, 10)},
+		limit, windowMs, elapsed, cost,
+	).Int64Slice()
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	return domain.RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      limit,
+		Remaining:  max(limit-int(res[1]), 0),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+		Reset:      time.Duration(windowMs-elapsed) * time.Millisecond,
+	}, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -10,6 +11,7 @@ type RedisConfig struct {
 	Addr     string
 	Password string
 	DB       int
+	PoolSize int
 }
 
 func NewRedisClient(cfg RedisConfig) (*redis.Client, error) {
@@ -17,9 +19,14 @@ func NewRedisClient(cfg RedisConfig) (*redis.Client, error) {
 		Addr:     cfg.Addr,
 		Password: cfg.Password,
 		DB:       cfg.DB,
-		PoolSize: 250,
+		PoolSize: cfg.PoolSize,
 	})
 
+	// Спан на каждую команду и pipeline
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		return nil, err
+	}
+
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
 		return nil, err
 	}
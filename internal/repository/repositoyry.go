@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/ArtemChadaev/RedGo/internal/domain"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
@@ -10,12 +12,31 @@ type Repository struct {
 	Incidents     domain.IncidentRepository
 	IncidentCashe domain.IncidentCacheRepository
 	Queues        domain.QueueRepository
+	Events        domain.EventRepository
+	APIKeys       domain.APIKeyRepository
+	APIKeyUsage   domain.APIKeyUsageRepository
+	Audit         domain.AuditRepository
+	Tenants       domain.TenantRepository
+	RateLimits    domain.RateLimitRepository
+	Retention     domain.CheckRetentionRepository
+}
+
+// Config — настройки репозиториев, которые приходят из конфига приложения
+type Config struct {
+	CacheTTL time.Duration // время жизни кэша активных инцидентов
 }
 
-func NewRepository(db *sqlx.DB, redis *redis.Client) *Repository {
+func NewRepository(db *sqlx.DB, redis *redis.Client, cfg Config) *Repository {
 	return &Repository{
 		Incidents:     NewIncidentRepository(db),
-		IncidentCashe: NewIncidentCasheRepository(redis),
+		IncidentCashe: NewIncidentCasheRepository(redis, cfg.CacheTTL),
 		Queues:        NewIncidentQueueRepository(redis),
+		Events:        NewIncidentEventRepository(redis),
+		APIKeys:       NewAPIKeyRepository(db),
+		APIKeyUsage:   NewAPIKeyUsageRepository(redis),
+		Audit:         NewAuditRepository(db),
+		Tenants:       NewTenantRepository(db),
+		RateLimits:    NewRateLimitRepository(redis),
+		Retention:     NewCheckRetentionRepository(db),
 	}
 }
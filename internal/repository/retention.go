@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// retentionLockID — реплики обслуживают партиции по очереди, а не создают одну и ту же дважды
+const retentionLockID int64 = 0x52657465 // "Rete"
+
+const (
+	checksTable            = "location_checks"
+	checksDefaultPartition = "location_checks_default"
+)
+
+type checkRetentionRepository struct {
+	db *sqlx.DB
+}
+
+func NewCheckRetentionRepository(db *sqlx.DB) domain.CheckRetentionRepository {
+	return &checkRetentionRepository{db: db}
+}
+
+// checkPartition — партиция location_checks с границами. Upper nil — MAXVALUE или партиция по умолчанию
+type checkPartition struct {
+	Name  string     `db:"name"`
+	Upper *time.Time `db:"upper"`
+}
+
+// partitions читает границы из каталога. Текст границы приводится к timestamptz в той же сессии,
+// поэтому часовой пояс сервера не важен
+func partitions(ctx context.Context, tx *sqlx.Tx) ([]checkPartition, error) {
+	var partitioned bool
+	if err := tx.GetContext(ctx, &partitioned, `SELECT relkind = 'p' FROM pg_class WHERE oid = $1::regclass`, checksTable); err != nil {
+		return nil, err
+	}
+	if !partitioned {
+		return nil, fmt.Errorf("%s is not partitioned: apply migrations first", checksTable)
+	}
+
+	var list []checkPartition
+	err := tx.SelectContext(ctx, &list, `
+		SELECT c.relname AS name,
+		       (regexp_match(pg_get_expr(c.relpartbound, c.oid), 'TO \(''([^'']+)''\)'))[1]::timestamptz AS upper
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::regclass
+		ORDER BY upper NULLS LAST
+	`, checksTable)
+	return list, err
+}
+
+func (r *checkRetentionRepository) EnsurePartitions(ctx context.Context, until time.Time) ([]string, error) {
+	var created []string
+	err := r.withLock(ctx, func(tx *sqlx.Tx) error {
+		list, err := partitions(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Новые сутки начинаются с верхней границы последней партиции, но не раньше сегодняшних:
+		// строки пропущенных дней остаются в default и удаляются по сроку построчно
+		from := time.Now().UTC().Truncate(24 * time.Hour)
+		for _, p := range list {
+			if p.Upper != nil && p.Upper.After(from) {
+				from = p.Upper.UTC()
+			}
+		}
+
+		for ; from.Before(until); from = from.Add(24 * time.Hour) {
+			name, err := createDayPartition(ctx, tx, from)
+			if err != nil {
+				return err
+			}
+			created = append(created, name)
+		}
+		return nil
+	})
+	return created, err
+}
+
+// createDayPartition создает сутки [from, from+24h) отдельной таблицей, забирает в нее строки из партиции
+// по умолчанию и только потом подключает: иначе ATTACH упадет на строках, уже лежащих в default
+func createDayPartition(ctx context.Context, tx *sqlx.Tx, from time.Time) (string, error) {
+	name := pq.QuoteIdentifier(checksTable + "_p" + from.Format("20060102"))
+	to := from.Add(24 * time.Hour)
+
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE `+name+` (LIKE `+checksTable+` INCLUDING DEFAULTS)`); err != nil {
+		return "", err
+	}
+	moveQuery := `
+		WITH moved AS (
+			DELETE FROM ` + checksDefaultPartition + ` WHERE created_at >= $1 AND created_at < $2 RETURNING *
+		)
+		INSERT INTO ` + name + ` SELECT * FROM moved`
+	if _, err := tx.ExecContext(ctx, moveQuery, from, to); err != nil {
+		return "", err
+	}
+	attachQuery := fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM (%s) TO (%s)`,
+		checksTable, name, pq.QuoteLiteral(from.Format(time.RFC3339)), pq.QuoteLiteral(to.Format(time.RFC3339)))
+	if _, err := tx.ExecContext(ctx, attachQuery); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (r *checkRetentionRepository) DropBefore(ctx context.Context, before time.Time, rollup bool) ([]string, int64, error) {
+	var (
+		dropped []string
+		purged  int64
+	)
+	err := r.withLock(ctx, func(tx *sqlx.Tx) error {
+		list, err := partitions(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range list {
+			if p.Name == checksDefaultPartition || p.Upper == nil || p.Upper.After(before) {
+				continue
+			}
+			name := pq.QuoteIdentifier(p.Name)
+			if rollup {
+				if err := rollupHourly(ctx, tx, name, before); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.ExecContext(ctx, `DROP TABLE `+name); err != nil {
+				return err
+			}
+			dropped = append(dropped, p.Name)
+		}
+
+		// В default строки разных суток вперемешку, их удаляем построчно
+		if rollup {
+			if err := rollupHourly(ctx, tx, checksDefaultPartition, before); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+checksDefaultPartition+` WHERE created_at < $1`, before)
+		if err != nil {
+			return err
+		}
+		purged, err = res.RowsAffected()
+		return err
+	})
+	return dropped, purged, err
+}
+
+// rollupHourly сворачивает строки table до before в почасовые агрегаты. Если час уже свернут
+// (строки одного часа из default удалялись в разные запуски), проверки складываются,
+// а уникальных пользователей берется максимум — точнее без исходных строк не посчитать
+func rollupHourly(ctx context.Context, tx *sqlx.Tx, table string, before time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO location_checks_hourly (tenant_id, map_id, hour, checks, unique_users)
+		SELECT tenant_id, map_id, date_trunc('hour', created_at), COUNT(*), COUNT(DISTINCT user_id)
+		FROM `+table+`
+		WHERE created_at < $1
+		GROUP BY 1, 2, 3
+		ON CONFLICT (tenant_id, map_id, hour) DO UPDATE
+		SET checks = location_checks_hourly.checks + EXCLUDED.checks,
+		    unique_users = GREATEST(location_checks_hourly.unique_users, EXCLUDED.unique_users)
+	`, before)
+	return err
+}
+
+// withLock выполняет fn в транзакции под advisory lock, который снимется вместе с ней
+func (r *checkRetentionRepository) withLock(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, retentionLockID); err != nil {
+		return wrapDBError(err)
+	}
+	if err := fn(tx); err != nil {
+		return wrapDBError(err)
+	}
+	return wrapDBError(tx.Commit())
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDropBeforeRollsUp(t *testing.T) {
+	m, db := testMigrator(t)
+	ctx := context.Background()
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// На пустой базе партиция legacy не нужна: без нее старые строки ложатся в default
+	if _, err := db.Exec(`DROP TABLE location_checks_legacy`); err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	oldDay := today.Add(-5 * 24 * time.Hour)
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := createDayPartition(ctx, tx, oldDay); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	inPartition := oldDay.Add(time.Hour)
+	inDefault := today.Add(-10*24*time.Hour + 2*time.Hour)
+	for _, row := range []struct {
+		userID int
+		at     time.Time
+	}{
+		{1, inPartition}, {1, inPartition}, {2, inPartition},
+		{3, inDefault}, {4, inDefault},
+		{5, time.Now()},
+	} {
+		if _, err := db.Exec(`INSERT INTO location_checks (user_id, map_id, x, y, created_at) VALUES ($1, 'arena', 0, 0, $2)`,
+			row.userID, row.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	retention := NewCheckRetentionRepository(db)
+	dropped, purged, err := retention.DropBefore(ctx, today.Add(-2*24*time.Hour), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"location_checks_p" + oldDay.Format("20060102")}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped %v, want %v", dropped, want)
+	}
+	if purged != 2 {
+		t.Errorf("purged %d rows from default, want 2", purged)
+	}
+
+	// Повторный запуск ничего не сворачивает дважды
+	if _, _, err := retention.DropBefore(ctx, today.Add(-2*24*time.Hour), true); err != nil {
+		t.Fatal(err)
+	}
+
+	var hourly []struct {
+		Hour        time.Time `db:"hour"`
+		Checks      int64     `db:"checks"`
+		UniqueUsers int64     `db:"unique_users"`
+	}
+	if err := db.Select(&hourly, `SELECT hour, checks, unique_users FROM location_checks_hourly WHERE tenant_id = 'default' AND map_id = 'arena' ORDER BY hour`); err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 2 ||
+		!hourly[0].Hour.Equal(inDefault) || hourly[0].Checks != 2 || hourly[0].UniqueUsers != 2 ||
+		!hourly[1].Hour.Equal(inPartition) || hourly[1].Checks != 3 || hourly[1].UniqueUsers != 2 {
+		t.Errorf("hourly = %+v", hourly)
+	}
+
+	var left int
+	if err := db.Get(&left, `SELECT COUNT(*) FROM location_checks`); err != nil {
+		t.Fatal(err)
+	}
+	if left != 1 {
+		t.Errorf("%d checks left, want today's 1", left)
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type tenantRepository struct {
+	db *sqlx.DB
+}
+
+func NewTenantRepository(db *sqlx.DB) domain.TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+var errTenantNotFound = domain.NewNotFoundError(domain.CodeNotFound, "tenant not found")
+
+func (r *tenantRepository) Create(ctx context.Context, t *domain.Tenant) error {
+	query := `
+		INSERT INTO tenants (id, name, webhook_url, rate_limit)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, t.ID, t.Name, t.WebhookURL, t.RateLimit).Scan(&t.CreatedAt)
+	return wrapDBError(err)
+}
+
+func (r *tenantRepository) Get(ctx context.Context, id string) (*domain.Tenant, error) {
+	var t domain.Tenant
+	query := `SELECT id, name, webhook_url, rate_limit, created_at FROM tenants WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &t, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errTenantNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return &t, nil
+}
+
+func (r *tenantRepository) List(ctx context.Context) ([]domain.Tenant, error) {
+	tenants := make([]domain.Tenant, 0)
+	query := `SELECT id, name, webhook_url, rate_limit, created_at FROM tenants ORDER BY id`
+
+	if err := r.db.SelectContext(ctx, &tenants, query); err != nil {
+		return nil, wrapDBError(err)
+	}
+	return tenants, nil
+}
+
+func (r *tenantRepository) Update(ctx context.Context, id string, input domain.UpdateTenantInput) (*domain.Tenant, error) {
+	query := `
+		UPDATE tenants
+		SET
+			name = COALESCE($1, name),
+			webhook_url = COALESCE($2, webhook_url),
+			rate_limit = COALESCE($3, rate_limit)
+		WHERE id = $4
+		RETURNING id, name, webhook_url, rate_limit, created_at
+	`
+
+	var t domain.Tenant
+	err := r.db.GetContext(ctx, &t, query, input.Name, input.WebhookURL, input.RateLimit, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errTenantNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return &t, nil
+}
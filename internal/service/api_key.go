@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+)
+
+const (
+	apiKeyPrefix    = "rg_" // по префиксу ключ легко найти в логах и сканерах секретов
+	apiKeyBytes     = 32
+	apiKeyShownSize = 10 // сколько символов ключа храним открыто для списка
+)
+
+type AuthConfig struct {
+	// LegacyKey — старый общий API_KEY: всё, кроме админки
+	LegacyKey string
+	// AdminKey — ADMIN_API_KEY, нужен чтобы выпустить первые ключи
+	AdminKey string
+	// CacheTTL — сколько держать проверенный ключ в памяти. Отзыв ключа вступает в силу не позже
+	CacheTTL time.Duration
+}
+
+type cachedKey struct {
+	key      *domain.APIKey
+	loadedAt time.Time
+}
+
+type apiKeyService struct {
+	repo  domain.APIKeyRepository
+	usage domain.APIKeyUsageRepository
+	cfg   AuthConfig
+
+	// bootstrap — ключи из окружения, они не лежат в базе
+	bootstrap map[string]*domain.Principal
+
+	mu    sync.RWMutex
+	cache map[string]cachedKey // по хэшу ключа
+}
+
+func NewAPIKeyService(repo domain.APIKeyRepository, usage domain.APIKeyUsageRepository, cfg AuthConfig) domain.APIKeyService {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 30 * time.Second
+	}
+
+	bootstrap := make(map[string]*domain.Principal)
+	if cfg.LegacyKey != "" {
+		bootstrap[cfg.LegacyKey] = &domain.Principal{
+			ID:       "env:API_KEY",
+			Name:     "API_KEY",
+			Kind:     domain.PrincipalAPIKey,
+			TenantID: domain.DefaultTenantID,
+			Scopes:   []domain.Scope{domain.ScopeIncidentsRead, domain.ScopeIncidentsWrite, domain.ScopeLocationCheck},
+		}
+	}
+	if cfg.AdminKey != "" {
+		bootstrap[cfg.AdminKey] = &domain.Principal{
+			ID:       "env:ADMIN_API_KEY",
+			Name:     "ADMIN_API_KEY",
+			Kind:     domain.PrincipalAPIKey,
+			TenantID: domain.DefaultTenantID,
+			Scopes:   []domain.Scope{domain.ScopeAdmin},
+		}
+	}
+
+	return &apiKeyService{
+		repo:      repo,
+		usage:     usage,
+		cfg:       cfg,
+		bootstrap: bootstrap,
+		cache:     make(map[string]cachedKey),
+	}
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*domain.Principal, error) {
+	if rawKey == "" {
+		return nil, domain.NewUnauthorizedError("api key required")
+	}
+
+	for k, p := range s.bootstrap {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(rawKey)) == 1 {
+			return p, nil
+		}
+	}
+
+	key, err := s.lookup(ctx, hashKey(rawKey))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, domain.NewUnauthorizedError("api key revoked")
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, domain.NewUnauthorizedError("api key expired")
+	}
+
+	return &domain.Principal{
+		ID:       "key:" + strconv.Itoa(key.ID),
+		Name:     key.Name,
+		Kind:     domain.PrincipalAPIKey,
+		TenantID: key.TenantID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// lookup читает ключ из кэша, а раз в CacheTTL — из базы, заодно обновляя last_used_at.
+// Пока база недоступна, устаревшая запись кэша остается в силе
+func (s *apiKeyService) lookup(ctx context.Context, hash string) (*domain.APIKey, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[hash]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < s.cfg.CacheTTL {
+		return cached.key, nil
+	}
+
+	key, err := s.repo.GetByHash(ctx, hash)
+	if err != nil {
+		// База недоступна — пускаем по последнему прочитанному ключу, как GetTenant.
+		// Отзыв при этом вступит в силу, когда база вернется
+		if ok && errors.Is(err, domain.ErrUnavailable) {
+			slog.WarnContext(ctx, "failed to refresh api key, using cached", "key_id", cached.key.ID, logging.Err(err))
+			return cached.key, nil
+		}
+		return nil, err
+	}
+
+	if key.RevokedAt == nil {
+		if err := s.repo.Touch(ctx, key.ID); err != nil {
+			slog.WarnContext(ctx, "failed to touch api key", "key_id", key.ID, logging.Err(err))
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = cachedKey{key: key, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, input domain.CreateAPIKeyInput) (*domain.APIKey, string, error) {
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", domain.NewValidationError(domain.CodeValidation, "request validation failed",
+			domain.FieldError{Field: "expires_at", Message: "must be in the future"})
+	}
+
+	// Свой тенант по умолчанию, чужой — только для platform admin
+	caller := domain.PrincipalFrom(ctx)
+	tenantID := domain.TenantFrom(ctx)
+	if input.TenantID != "" && input.TenantID != tenantID {
+		if !caller.IsPlatformAdmin() {
+			return nil, "", domain.NewForbiddenError("cannot issue keys for another tenant")
+		}
+		tenantID = input.TenantID
+	}
+
+	raw, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &domain.APIKey{
+		TenantID:  tenantID,
+		Name:      input.Name,
+		Prefix:    raw[:apiKeyShownSize],
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, key, hashKey(raw)); err != nil {
+		return nil, "", err
+	}
+
+	slog.InfoContext(ctx, "api key issued", "key_id", key.ID, "name", key.Name, "tenant_id", key.TenantID, "scopes", key.Scopes)
+	return key, raw, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.repo.List(ctx, keysTenant(ctx))
+}
+
+// keysTenant — чьи ключи видит вызывающий: platform admin все, остальные только своего тенанта
+func keysTenant(ctx context.Context) string {
+	if domain.PrincipalFrom(ctx).IsPlatformAdmin() {
+		return ""
+	}
+	return domain.TenantFrom(ctx)
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, id int) error {
+	if err := s.repo.Revoke(ctx, id, keysTenant(ctx)); err != nil {
+		return err
+	}
+
+	// Чистим свой кэш сразу, остальные инстансы забудут ключ через CacheTTL
+	s.mu.Lock()
+	for hash, cached := range s.cache {
+		if cached.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+	s.mu.Unlock()
+
+	slog.InfoContext(ctx, "api key revoked", "key_id", id)
+	return nil
+}
+
+// RecordUsage не должен ронять запрос, поэтому ошибки только в лог
+func (s *apiKeyService) RecordUsage(ctx context.Context, p *domain.Principal, route string) {
+	if p == nil {
+		return
+	}
+	if err := s.usage.Incr(ctx, p.ID, route); err != nil {
+		slog.WarnContext(ctx, "failed to record api key usage", "principal", p.ID, logging.Err(err))
+	}
+}
+
+func (s *apiKeyService) KeyUsage(ctx context.Context, id int, days int) (map[string]map[string]int64, error) {
+	if days <= 0 || days > 30 {
+		days = 7
+	}
+
+	// Проверяем, что ключ существует, иначе пустой ответ было бы не отличить от опечатки в id
+	keys, err := s.repo.List(ctx, keysTenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, k := range keys {
+		if k.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, domain.NewNotFoundError(domain.CodeNotFound, "api key not found")
+	}
+
+	return s.usage.Get(ctx, "key:"+strconv.Itoa(id), days)
+}
+
+func generateKey() (string, error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Join(errors.New("failed to generate api key"), err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
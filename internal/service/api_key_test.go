@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// fakeAPIKeys — один ключ в памяти; err, если задан, возвращается вместо него
+type fakeAPIKeys struct {
+	domain.APIKeyRepository
+
+	mu  sync.Mutex
+	key *domain.APIKey
+	err error
+}
+
+func (f *fakeAPIKeys) GetByHash(context.Context, string) (*domain.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	key := *f.key
+	return &key, nil
+}
+
+func (f *fakeAPIKeys) Touch(context.Context, int) error { return nil }
+
+func TestAuthenticateWhenDatabaseDown(t *testing.T) {
+	const rawKey = "rg_test"
+	unavailable := domain.NewUnavailableError("database unavailable", errors.New("dial tcp: connection refused"))
+
+	tests := []struct {
+		name   string
+		cached bool
+		err    error
+		want   error // nil — ключ принят
+	}{
+		{"stale cache on unavailable", true, unavailable, nil},
+		{"no cache on unavailable", false, unavailable, domain.ErrUnavailable},
+		{"deleted key is not served from cache", true, domain.NewUnauthorizedError("invalid api key"), domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAPIKeys{key: &domain.APIKey{ID: 1, Name: "game", TenantID: "studio-a", Scopes: []domain.Scope{domain.ScopeLocationCheck}}}
+			// Наносекундный TTL: каждый вызов после первого идет в базу
+			svc := NewAPIKeyService(repo, nil, AuthConfig{CacheTTL: time.Nanosecond})
+
+			ctx := context.Background()
+			if tt.cached {
+				if _, err := svc.Authenticate(ctx, rawKey); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			repo.mu.Lock()
+			repo.err = tt.err
+			repo.mu.Unlock()
+
+			p, err := svc.Authenticate(ctx, rawKey)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("err = %v, want cached key", err)
+				}
+				if p.ID != "key:1" || p.TenantID != "studio-a" {
+					t.Errorf("principal = %+v", p)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
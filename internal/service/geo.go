@@ -0,0 +1,83 @@
+package service
+
+import (
+	"math"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// earthRadiusMeters — средний радиус Земли для формулы гаверсинусов
+const earthRadiusMeters = 6371008.8
+
+// distance считает расстояние между двумя точками в единицах выбранной системы:
+// игровые единицы для game и метры для wgs84
+func distance(cs domain.CoordinateSystem, x1, y1, x2, y2 float64) float64 {
+	if cs == domain.CoordinateSystemWGS84 {
+		return haversine(x1, y1, x2, y2)
+	}
+
+	dx := x1 - x2
+	dy := y1 - y2
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// haversine — расстояние по большому кругу в метрах, аргументы (lon, lat) в градусах
+func haversine(lon1, lat1, lon2, lat2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// validateCoordinates проверяет диапазоны. Для game допустимо всё, кроме NaN/Inf.
+// Указатели позволяют проверять частичное обновление, где пришла только одна координата
+func validateCoordinates(cs domain.CoordinateSystem, x, y *float64) error {
+	if x != nil && !isFinite(*x) {
+		return domain.NewInvalidCoordinatesError("x", "coordinate must be a finite number")
+	}
+	if y != nil && !isFinite(*y) {
+		return domain.NewInvalidCoordinatesError("y", "coordinate must be a finite number")
+	}
+
+	if cs != domain.CoordinateSystemWGS84 {
+		return nil
+	}
+
+	if x != nil && (*x < -180 || *x > 180) {
+		return domain.NewInvalidCoordinatesError("x", "longitude must be in [-180, 180]")
+	}
+	if y != nil && (*y < -90 || *y > 90) {
+		return domain.NewInvalidCoordinatesError("y", "latitude must be in [-90, 90]")
+	}
+
+	return nil
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// bearing — направление от игрока к инциденту в градусах [0, 360) по часовой стрелке.
+// Для game ноль смотрит вдоль +Y, для wgs84 это начальный азимут на север
+func bearing(cs domain.CoordinateSystem, fromX, fromY, toX, toY float64) float64 {
+	var deg float64
+
+	if cs == domain.CoordinateSystemWGS84 {
+		phi1 := fromY * math.Pi / 180
+		phi2 := toY * math.Pi / 180
+		dLambda := (toX - fromX) * math.Pi / 180
+
+		y := math.Sin(dLambda) * math.Cos(phi2)
+		x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+		deg = math.Atan2(y, x) * 180 / math.Pi
+	} else {
+		deg = math.Atan2(toX-fromX, toY-fromY) * 180 / math.Pi
+	}
+
+	return math.Mod(deg+360, 360)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lon1, lat1, lon2, lat2 float64
+		want                   float64 // метры
+		tolerance              float64
+	}{
+		{"same point", 37.6173, 55.7558, 37.6173, 55.7558, 0, 1e-6},
+		{"one degree of longitude on the equator", 0, 0, 1, 0, 111195, 1},
+		{"one degree of latitude on the prime meridian", 0, 0, 0, 1, 111195, 1},
+		{"Moscow to Saint Petersburg", 37.6173, 55.7558, 30.3351, 59.9343, 634000, 2000},
+		{"antipodes", 0, 0, 180, 0, math.Pi * earthRadiusMeters, 1},
+		{"across the antimeridian", 179.5, 0, -179.5, 0, 111195, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := haversine(tt.lon1, tt.lat1, tt.lon2, tt.lat2)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("haversine() = %.1f, want %.1f ± %.1f", got, tt.want, tt.tolerance)
+			}
+			if back := haversine(tt.lon2, tt.lat2, tt.lon1, tt.lat1); math.Abs(back-got) > 1e-6 {
+				t.Errorf("haversine is not symmetric: %.3f vs %.3f", got, back)
+			}
+		})
+	}
+}
+
+func TestDistanceGame(t *testing.T) {
+	if got := distance(domain.CoordinateSystemGame, 0, 0, 3, 4); got != 5 {
+		t.Errorf("distance(game) = %v, want 5", got)
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		cs        domain.CoordinateSystem
+		x, y      *float64
+		wantField string // "" — ошибки нет
+	}{
+		{"game accepts any finite value", domain.CoordinateSystemGame, f(1e6), f(-1e6), ""},
+		{"game rejects NaN", domain.CoordinateSystemGame, f(math.NaN()), f(0), "x"},
+		{"game rejects Inf", domain.CoordinateSystemGame, f(0), f(math.Inf(1)), "y"},
+		{"wgs84 accepts zero", domain.CoordinateSystemWGS84, f(0), f(0), ""},
+		{"wgs84 accepts bounds", domain.CoordinateSystemWGS84, f(-180), f(90), ""},
+		{"wgs84 longitude out of range", domain.CoordinateSystemWGS84, f(180.1), f(0), "x"},
+		{"wgs84 latitude out of range", domain.CoordinateSystemWGS84, f(0), f(-90.1), "y"},
+		{"partial update checks only given coordinate", domain.CoordinateSystemWGS84, nil, f(45), ""},
+		{"partial update out of range", domain.CoordinateSystemWGS84, f(-200), nil, "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCoordinates(tt.cs, tt.x, tt.y)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var derr *domain.Error
+			if !errors.As(err, &derr) || !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("want validation error, got %v", err)
+			}
+			if len(derr.Fields) != 1 || derr.Fields[0].Field != tt.wantField {
+				t.Errorf("fields = %+v, want one error on %q", derr.Fields, tt.wantField)
+			}
+		})
+	}
+}
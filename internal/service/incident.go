@@ -2,42 +2,97 @@ package service
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/metrics"
+	"github.com/ArtemChadaev/RedGo/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type IncidentConfig struct {
-	StatsWindow     int     // за сколько минут считать юзеров
-	DetectionRadius float64 // радиус обнаружения в игровых единицах
+	StatsWindow      int                     // за сколько минут считать юзеров
+	DetectionRadius  float64                 // радиус обнаружения: игровые единицы или метры для wgs84
+	CoordinateSystem domain.CoordinateSystem // система координат: game (по умолчанию) или wgs84
 }
 type incidentService struct {
-	repo  domain.IncidentRepository
-	cashe domain.IncidentCacheRepository
-	queue domain.QueueRepository
-	cfg   IncidentConfig
+	repo   domain.IncidentRepository
+	cashe  domain.IncidentCacheRepository
+	queue  domain.QueueRepository
+	events domain.EventRepository
+	audit  domain.AuditRepository
+
+	// cfg меняется на лету при перезагрузке конфига, читаем через config()
+	cfg atomic.Pointer[IncidentConfig]
+
+	// lastActive — последний загруженный активный набор по TenantKey(тенант, карта).
+	// Им отвечаем, когда нет ни кэша, ни Postgres
+	lastMu     sync.RWMutex
+	lastActive map[string][]domain.Incident
 }
 
-func NewIncidentService(repo domain.IncidentRepository, cashe domain.IncidentCacheRepository, queue domain.QueueRepository, cfg IncidentConfig) domain.IncidentService {
-	return &incidentService{
-		repo:  repo,
-		cashe: cashe,
-		queue: queue,
-		cfg:   cfg,
+func NewIncidentService(repo domain.IncidentRepository, cashe domain.IncidentCacheRepository, queue domain.QueueRepository, events domain.EventRepository, audit domain.AuditRepository, cfg IncidentConfig) domain.IncidentService {
+	if cfg.CoordinateSystem == "" {
+		cfg.CoordinateSystem = domain.CoordinateSystemGame
+	}
+
+	s := &incidentService{
+		repo:   repo,
+		cashe:  cashe,
+		queue:  queue,
+		events: events,
+		audit:  audit,
+
+		lastActive: make(map[string][]domain.Incident),
 	}
+	s.cfg.Store(&cfg)
+	return s
+}
+
+func (s *incidentService) config() *IncidentConfig {
+	return s.cfg.Load()
+}
+
+// updateConfig подменяет радиус и окно статистики целиком, текущие проверки дорабатывают со старыми.
+// Система координат на лету не меняется: от нее зависит смысл уже сохраненных координат
+func (s *incidentService) updateConfig(cfg IncidentConfig) {
+	cfg.CoordinateSystem = s.config().CoordinateSystem
+	s.cfg.Store(&cfg)
 }
 
 func (s *incidentService) CreateIncident(ctx context.Context, inc *domain.Incident) error {
+	ctx, span := tracing.Start(ctx, "IncidentService.CreateIncident")
+	defer span.End()
+
+	if err := validateCoordinates(s.config().CoordinateSystem, inc.X, inc.Y); err != nil {
+		return err
+	}
+	if inc.MapID == "" {
+		inc.MapID = domain.DefaultMapID
+	}
+
 	if err := s.repo.Create(ctx, inc); err != nil {
 		return err
 	}
 	if err := s.cashe.DeleteActive(ctx); err != nil {
-		log.Printf("failed to delete active cache: %v", err)
+		slog.WarnContext(ctx, "failed to delete active cache", logging.Err(err))
 	}
+
+	s.recordChange(ctx, domain.EventIncidentCreated, inc)
 	return nil
 }
 
-func (s *incidentService) GetIncidents(ctx context.Context, page, pageSize int) ([]domain.Incident, error) {
+func (s *incidentService) GetIncidents(ctx context.Context, filter domain.IncidentFilter, page, pageSize int) ([]domain.Incident, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.GetIncidents")
+	defer span.End()
+
 	limit := pageSize
 	if limit <= 0 {
 		limit = 10000
@@ -47,86 +102,359 @@ func (s *incidentService) GetIncidents(ctx context.Context, page, pageSize int)
 	if offset < 0 {
 		offset = 0
 	}
-	return s.repo.GetAll(ctx, limit, offset)
+	return s.repo.GetAll(ctx, filter, limit, offset)
 }
 
 func (s *incidentService) GetIncidentByID(ctx context.Context, id int) (*domain.Incident, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.GetIncidentByID")
+	defer span.End()
+
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *incidentService) Update(ctx context.Context, id int, input domain.UpdateIncidentInput) error {
+func (s *incidentService) Update(ctx context.Context, id int, input domain.UpdateIncidentInput, version int) (*domain.Incident, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.Update")
+	defer span.End()
+
+	if err := validateCoordinates(s.config().CoordinateSystem, input.X, input.Y); err != nil {
+		return nil, err
+	}
+
+	// Старое состояние нужно, чтобы отличить смену статуса от обычной правки.
+	// Ошибку не проверяем: если записи нет, Update ниже вернет ErrIncidentNotFound
+	before, _ := s.repo.GetByID(ctx, id)
+
 	// 1. Вызываем метод репозитория с id и структурой для обновления
 	// Мы больше не присваиваем id внутрь структуры, а передаем его вторым аргументом
-	if err := s.repo.Update(ctx, id, input); err != nil {
-		return err
+	after, err := s.repo.Update(ctx, id, input, version)
+	if err != nil {
+		return nil, err
 	}
 
 	// 2. Инвалидация кэша
 	// После обновления данных в БД старый кэш "GetAllActive" становится неактуальным
 	if err := s.cashe.DeleteActive(ctx); err != nil {
 		// Логируем ошибку, но не прерываем выполнение, так как БД уже обновлена
-		log.Printf("WARNING: failed to delete active cache after update: %v", err)
+		slog.WarnContext(ctx, "failed to delete active cache", logging.Err(err))
+	}
+
+	// 3. Аудит и событие для подписчиков SSE
+	eventType := domain.EventIncidentUpdated
+	if before != nil && before.Status != after.Status {
+		eventType = domain.EventIncidentStatusChanged
 	}
+	s.recordChange(ctx, eventType, after)
 
+	return after, nil
+}
+
+func (s *incidentService) DeleteIncident(ctx context.Context, id int, version int) error {
+	ctx, span := tracing.Start(ctx, "IncidentService.DeleteIncident")
+	defer span.End()
+
+	inc, err := s.repo.Delete(ctx, id, version)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cashe.DeleteActive(ctx); err != nil {
+		slog.WarnContext(ctx, "failed to delete active cache", logging.Err(err))
+	}
+
+	s.recordChange(ctx, domain.EventIncidentDeleted, inc)
 	return nil
 }
 
-func (s *incidentService) DeleteIncident(ctx context.Context, id int) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
+func (s *incidentService) RestoreIncident(ctx context.Context, id int) (*domain.Incident, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.RestoreIncident")
+	defer span.End()
+
+	inc, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cashe.DeleteActive(ctx); err != nil {
+		slog.WarnContext(ctx, "failed to delete active cache", logging.Err(err))
+	}
+
+	s.recordChange(ctx, domain.EventIncidentRestored, inc)
+	return inc, nil
+}
+
+func (s *incidentService) PurgeIncident(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "IncidentService.PurgeIncident")
+	defer span.End()
+
+	inc, err := s.repo.Purge(ctx, id)
+	if err != nil {
 		return err
 	}
 
 	if err := s.cashe.DeleteActive(ctx); err != nil {
-		log.Printf("failed to delete active cache: %v", err)
+		slog.WarnContext(ctx, "failed to delete active cache", logging.Err(err))
 	}
+
+	s.recordChange(ctx, domain.EventIncidentPurged, inc)
 	return nil
 }
 
-func (s *incidentService) CheckLocation(ctx context.Context, userID int, x, y float64) ([]domain.Incident, error) {
-	if err := s.repo.SaveCheck(ctx, userID, x, y); err != nil {
+func (s *incidentService) GetAudit(ctx context.Context, id int, limit int) ([]domain.AuditRecord, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.GetAudit")
+	defer span.End()
+
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return s.audit.ListByIncident(ctx, id, limit)
+}
+
+func (s *incidentService) SubscribeEvents(ctx context.Context) (<-chan domain.IncidentEvent, error) {
+	return s.events.Subscribe(ctx)
+}
+
+func (s *incidentService) EventsSince(ctx context.Context, lastID string) ([]domain.IncidentEvent, error) {
+	return s.events.Since(ctx, lastID)
+}
+
+// recordChange пишет аудит с автором из контекста и рассылает событие об изменении.
+// Как и с кэшем, ошибки только логируются: база уже изменена, а подписчики догонят состояние через GET
+func (s *incidentService) recordChange(ctx context.Context, eventType domain.IncidentEventType, inc *domain.Incident) {
+	actor := domain.PrincipalFrom(ctx)
+	if actor == nil {
+		actor = &domain.Principal{ID: "system", Name: "system", Kind: "system"}
+	}
+
+	if err := s.audit.Save(ctx, domain.AuditRecord{
+		IncidentID: inc.ID,
+		Action:     eventType,
+		ActorID:    actor.ID,
+		ActorName:  actor.Name,
+		ActorKind:  actor.Kind,
+		Incident:   *inc,
+	}); err != nil {
+		slog.ErrorContext(ctx, "failed to write audit", "action", eventType, "incident_id", inc.ID, "actor_id", actor.ID, logging.Err(err))
+	}
+
+	ev := domain.IncidentEvent{
+		Type:     eventType,
+		Incident: *inc,
+		Actor:    actor.Name,
+		At:       time.Now().UTC(),
+	}
+
+	if err := s.events.Publish(ctx, ev); err != nil {
+		slog.WarnContext(ctx, "failed to publish incident event", "type", eventType, "incident_id", inc.ID, logging.Err(err))
+	}
+}
+
+func (s *incidentService) CheckLocation(ctx context.Context, check domain.LocationCheck, opts domain.CheckOptions) (*domain.LocationCheckResult, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.CheckLocation")
+	defer span.End()
+
+	if err := validateCoordinates(s.config().CoordinateSystem, &check.X, &check.Y); err != nil {
+		return nil, err
+	}
+	if check.MapID == "" {
+		check.MapID = domain.DefaultMapID
+	}
+
+	degraded, err := s.saveDegraded(ctx, s.repo.SaveCheck(ctx, check), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	incidents, stale := s.activeIncidents(ctx, check.MapID)
+	res := s.match(check, incidents, opts)
+	res.Degraded = degraded || stale
+	if res.Degraded {
+		metrics.DegradedChecks.Inc()
+	}
+	span.SetAttributes(attribute.String("map_id", check.MapID), attribute.Int("matches", len(res.Incidents)))
+
+	for _, n := range res.Incidents {
+		if err := s.queue.PushWebhookTask(ctx, domain.WebhookTask{WebhookPayload: domain.WebhookPayload{
+			IncidentID: n.ID,
+			UserID:     check.UserID,
+			MapID:      check.MapID,
+			X:          check.X,
+			Y:          check.Y,
+			Distance:   n.Distance,
+		}}); err != nil {
+			slog.WarnContext(ctx, "failed to push webhook task", "incident_id", n.ID, logging.Err(err))
+		}
+	}
+
+	if opts.Limit > 0 && len(res.Incidents) > opts.Limit {
+		res.Incidents = res.Incidents[:opts.Limit]
+	}
+
+	return res, nil
+}
+
+func (s *incidentService) CheckLocations(ctx context.Context, checks []domain.LocationCheck, opts domain.CheckOptions) (map[int]*domain.LocationCheckResult, error) {
+	ctx, span := tracing.Start(ctx, "IncidentService.CheckLocations")
+	defer span.End()
+
+	// Результаты ключуются по user_id: повтор молча затер бы первую проверку
+	seen := make(map[int]int, len(checks))
+	for i := range checks {
+		if first, ok := seen[checks[i].UserID]; ok {
+			return nil, domain.NewValidationError(domain.CodeValidation, "duplicate user_id in batch", domain.FieldError{
+				Field:   fmt.Sprintf("checks[%d].user_id", i),
+				Message: fmt.Sprintf("user_id %d already checked in checks[%d]", checks[i].UserID, first),
+			})
+		}
+		seen[checks[i].UserID] = i
+
+		if err := validateCoordinates(s.config().CoordinateSystem, &checks[i].X, &checks[i].Y); err != nil {
+			// Поле указываем с индексом, чтобы клиент понял, какая из проверок в пачке плохая
+			var derr *domain.Error
+			if errors.As(err, &derr) {
+				for j := range derr.Fields {
+					derr.Fields[j].Field = fmt.Sprintf("checks[%d].%s", i, derr.Fields[j].Field)
+				}
+			}
+			return nil, err
+		}
+		if checks[i].MapID == "" {
+			checks[i].MapID = domain.DefaultMapID
+		}
+	}
+
+	degraded, err := s.saveDegraded(ctx, s.repo.SaveChecks(ctx, checks), len(checks))
+	if err != nil {
 		return nil, err
 	}
 
-	// Здесь кэш важен, так как запросов много
-	incidents, err := s.cashe.GetActive(ctx)
+	// Активный набор грузим один раз на карту, а не на каждого игрока
+	byMap := make(map[string][]domain.Incident)
+	staleMaps := make(map[string]bool)
+	results := make(map[int]*domain.LocationCheckResult, len(checks))
+	var tasks []domain.WebhookTask
+
+	for _, check := range checks {
+		incidents, ok := byMap[check.MapID]
+		if !ok {
+			incidents, staleMaps[check.MapID] = s.activeIncidents(ctx, check.MapID)
+			byMap[check.MapID] = incidents
+		}
+
+		res := s.match(check, incidents, opts)
+		res.Degraded = degraded || staleMaps[check.MapID]
+		if res.Degraded {
+			metrics.DegradedChecks.Inc()
+		}
+		for _, n := range res.Incidents {
+			tasks = append(tasks, domain.WebhookTask{WebhookPayload: domain.WebhookPayload{
+				IncidentID: n.ID,
+				UserID:     check.UserID,
+				MapID:      check.MapID,
+				X:          check.X,
+				Y:          check.Y,
+				Distance:   n.Distance,
+			}})
+		}
+
+		if opts.Limit > 0 && len(res.Incidents) > opts.Limit {
+			res.Incidents = res.Incidents[:opts.Limit]
+		}
+		results[check.UserID] = res
+	}
+
+	if err := s.queue.PushWebhookTasks(ctx, tasks); err != nil {
+		slog.WarnContext(ctx, "failed to push webhook tasks", "count", len(tasks), logging.Err(err))
+	}
+
+	return results, nil
+}
+
+// activeIncidents — активные инциденты карты из кэша, базы или, если обе недоступны, из памяти.
+// stale = true — набор из памяти и мог устареть
+func (s *incidentService) activeIncidents(ctx context.Context, mapID string) (incidents []domain.Incident, stale bool) {
+	ctx, span := tracing.Start(ctx, "IncidentService.activeIncidents")
+	defer span.End()
+
+	key := domain.TenantKey(domain.TenantFrom(ctx), mapID)
+
+	incidents, err := s.cashe.GetActive(ctx, mapID)
 	if err != nil || incidents == nil {
-		incidents, err = s.repo.GetAllActive(ctx)
-		if err == nil {
-			_ = s.cashe.SetActive(ctx, incidents)
+		incidents, err = s.repo.GetAllActive(ctx, mapID)
+		if err != nil {
+			// Ни кэша, ни базы: деградируем до последнего известного набора, если он был
+			s.lastMu.RLock()
+			last, ok := s.lastActive[key]
+			s.lastMu.RUnlock()
+			slog.WarnContext(ctx, "active incidents unavailable, using last known set", "map_id", mapID, "known", ok, logging.Err(err))
+			return last, true
 		}
+		_ = s.cashe.SetActive(ctx, mapID, incidents)
 	}
 
-	var nearby []domain.Incident
-	radiusSq := s.cfg.DetectionRadius * s.cfg.DetectionRadius
+	s.lastMu.Lock()
+	s.lastActive[key] = incidents
+	s.lastMu.Unlock()
+
+	return incidents, false
+}
+
+// saveDegraded решает, что делать с ошибкой сохранения проверок. Недоступный Postgres не повод
+// оставить игрока без ответа: проверки теряются, но ответ строится дальше с degraded = true
+func (s *incidentService) saveDegraded(ctx context.Context, err error, count int) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	if errors.Is(err, domain.ErrUnavailable) {
+		slog.WarnContext(ctx, "postgres unavailable, location checks not saved", "count", count, logging.Err(err))
+		return true, nil
+	}
+	return false, err
+}
+
+// match находит инциденты в радиусе, считает расстояние и направление и сортирует
+// от ближнего к дальнему. Вебхуки и limit оставлены вызывающему: оповещаем обо всех совпадениях,
+// даже если клиент попросил вернуть только часть
+func (s *incidentService) match(check domain.LocationCheck, incidents []domain.Incident, opts domain.CheckOptions) *domain.LocationCheckResult {
+	cfg := s.config()
+	res := &domain.LocationCheckResult{
+		Incidents: make([]domain.NearbyIncident, 0),
+	}
 
 	for _, inc := range incidents {
 		if inc.X == nil || inc.Y == nil {
-			log.Printf("WARNING: incident %d has nil coordinates", inc.ID)
+			slog.Warn("incident has nil coordinates", "incident_id", inc.ID)
 			continue
 		}
 
-		dx := x - *inc.X
-		dy := y - *inc.Y
+		n := domain.NearbyIncident{
+			Incident: inc,
+			Distance: distance(cfg.CoordinateSystem, check.X, check.Y, *inc.X, *inc.Y),
+			Bearing:  bearing(cfg.CoordinateSystem, check.X, check.Y, *inc.X, *inc.Y),
+		}
 
-		if dx*dx+dy*dy <= radiusSq {
-			nearby = append(nearby, inc)
+		if n.Distance <= cfg.DetectionRadius {
+			res.Incidents = append(res.Incidents, n)
+			continue
+		}
 
-			if err := s.queue.PushWebhookTask(ctx, domain.WebhookTask{
-				IncidentID: inc.ID,
-				UserID:     userID,
-				X:          x,
-				Y:          y,
-			}); err != nil {
-				log.Printf("WARNING: failed to push webhook task for incident %d: %v", inc.ID, err)
-			}
+		if opts.IncludeNearest && (res.Warning == nil || n.Distance < res.Warning.Distance) {
+			res.Warning = &n
 		}
 	}
 
-	return nearby, nil
+	sort.Slice(res.Incidents, func(i, j int) bool {
+		return res.Incidents[i].Distance < res.Incidents[j].Distance
+	})
+
+	metrics.LocationMatches.Observe(float64(len(res.Incidents)))
+	return res
 }
 
 func (s *incidentService) GetStats(ctx context.Context) (int, error) {
-	return s.repo.GetStats(ctx, s.cfg.StatsWindow)
+	ctx, span := tracing.Start(ctx, "IncidentService.GetStats")
+	defer span.End()
+
+	return s.repo.GetStats(ctx, s.config().StatsWindow)
 }
 
 func (s *incidentService) HealthCheckDB(ctx context.Context) error {
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+func TestCheckLocationsRejectsDuplicateUserID(t *testing.T) {
+	// До сохранения и поиска инцидентов дело не доходит, репозитории не нужны
+	s := NewIncidentService(nil, nil, nil, nil, nil, IncidentConfig{StatsWindow: 10, DetectionRadius: 10})
+
+	_, err := s.CheckLocations(context.Background(), []domain.LocationCheck{
+		{UserID: 1, X: 1, Y: 1},
+		{UserID: 2, X: 2, Y: 2},
+		{UserID: 1, X: 3, Y: 3},
+	}, domain.CheckOptions{})
+
+	var derr *domain.Error
+	if !errors.As(err, &derr) || !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("want validation error, got %v", err)
+	}
+	if len(derr.Fields) != 1 || derr.Fields[0].Field != "checks[2].user_id" {
+		t.Errorf("fields = %+v, want one error on checks[2].user_id", derr.Fields)
+	}
+}
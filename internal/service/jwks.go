@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+)
+
+// jwksMissRefresh — не чаще этого перечитываем JWKS из-за неизвестного kid,
+// иначе поток мусорных токенов превратится в поток запросов к identity provider
+const jwksMissRefresh = time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksSource — набор публичных ключей из файла или по URL, перечитывается раз в refresh
+type jwksSource struct {
+	location string
+	refresh  time.Duration
+	client   *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+}
+
+func newJWKSSource(location string, refresh time.Duration) *jwksSource {
+	return &jwksSource{
+		location: location,
+		refresh:  refresh,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// key ищет ключ по kid. Пустой kid допустим, если ключ в наборе один
+func (s *jwksSource) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	keys, loadedAt, attemptedAt := s.keys, s.loadedAt, s.attemptedAt
+	s.mu.RUnlock()
+
+	k, found := lookupJWK(keys, kid)
+	reload := keys == nil || time.Since(loadedAt) > s.refresh
+	if found && !reload {
+		return k, nil
+	}
+
+	// Набор устарел или kid неизвестен (провайдер мог ротировать ключи).
+	// После неудачной попытки ждем jwksMissRefresh, чтобы не долбить провайдер на каждый запрос
+	if keys == nil || time.Since(attemptedAt) > jwksMissRefresh {
+		fresh, err := s.load(ctx)
+		switch {
+		case err == nil:
+			keys = fresh
+		case keys == nil:
+			return nil, domain.NewUnavailableError("jwks unavailable", err)
+		default:
+			// Провайдер недоступен — работаем на старом наборе
+			slog.WarnContext(ctx, "failed to refresh jwks, using previous keys", "location", s.location, logging.Err(err))
+		}
+	}
+
+	if k, ok := lookupJWK(keys, kid); ok {
+		return k, nil
+	}
+	return nil, domain.NewUnauthorizedError("unknown token signing key")
+}
+
+func lookupJWK(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, true
+		}
+	}
+	k, ok := keys[kid]
+	return k, ok
+}
+
+func (s *jwksSource) load(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	s.mu.Lock()
+	s.attemptedAt = time.Now()
+	s.mu.Unlock()
+
+	raw, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// Ключи шифрования нам не нужны
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "skipping jwk", "kid", k.Kid, logging.Err(err))
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable signing keys")
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return keys, nil
+}
+
+func (s *jwksSource) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.location, "http://") && !strings.HasPrefix(s.location, "https://") {
+		return os.ReadFile(s.location)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeB64(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeB64(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeB64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeB64(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeB64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeB64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/metrics"
+)
+
+// DefaultRateLimits — лимиты, если RATE_LIMITS не задан. Игрок шлет позицию не чаще 10 раз в секунду,
+// один ключ — игровой сервер со многими игроками
+const DefaultRateLimits = "POST /api/v1/location/check key=6000/1m user=600/1m; " +
+	"POST /api/v1/location/check/batch key=600/1m; " +
+	"GET /api/v1/location/stream key=6000/1m user=600/1m"
+
+// ParseRateLimits разбирает правила вида
+// "POST /api/v1/location/check key=6000/1m user=600/1m; GET /api/v1/location/stream ip=60/1m".
+// "off" отключает лимиты
+func ParseRateLimits(spec string) ([]domain.RateLimitRule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "off" {
+		return nil, nil
+	}
+
+	var rules []domain.RateLimitRule
+	for _, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("rate limit %q: want \"METHOD /path by=limit/window ...\"", strings.TrimSpace(part))
+		}
+
+		route := strings.ToUpper(fields[0]) + " " + fields[1]
+		for _, f := range fields[2:] {
+			rule, err := parseRateLimitRule(route, f)
+			if err != nil {
+				return nil, fmt.Errorf("rate limit %q: %w", route, err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func parseRateLimitRule(route, field string) (domain.RateLimitRule, error) {
+	by, rest, ok := strings.Cut(field, "=")
+	if !ok {
+		return domain.RateLimitRule{}, fmt.Errorf("%q: want by=limit/window", field)
+	}
+
+	switch domain.RateLimitBy(by) {
+	case domain.RateLimitByKey, domain.RateLimitByIP, domain.RateLimitByUser:
+	default:
+		return domain.RateLimitRule{}, fmt.Errorf("%q: by must be key, ip or user", field)
+	}
+
+	limitStr, windowStr, ok := strings.Cut(rest, "/")
+	if !ok {
+		return domain.RateLimitRule{}, fmt.Errorf("%q: want by=limit/window", field)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		return domain.RateLimitRule{}, fmt.Errorf("%q: limit must be a positive integer", field)
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window < time.Second {
+		return domain.RateLimitRule{}, fmt.Errorf("%q: window must be a duration of at least 1s", field)
+	}
+
+	return domain.RateLimitRule{Route: route, By: domain.RateLimitBy(by), Limit: limit, Window: window}, nil
+}
+
+type rateLimitService struct {
+	repo  domain.RateLimitRepository
+	rules map[string][]domain.RateLimitRule
+
+	mu       sync.Mutex
+	rejected map[string]int64
+}
+
+func NewRateLimitService(repo domain.RateLimitRepository, rules []domain.RateLimitRule) domain.RateLimitService {
+	byRoute := make(map[string][]domain.RateLimitRule)
+	for _, r := range rules {
+		byRoute[r.Route] = append(byRoute[r.Route], r)
+	}
+
+	return &rateLimitService{
+		repo:     repo,
+		rules:    byRoute,
+		rejected: make(map[string]int64),
+	}
+}
+
+func (s *rateLimitService) Rules(route string) []domain.RateLimitRule {
+	return s.rules[route]
+}
+
+func (s *rateLimitService) Allow(ctx context.Context, rule domain.RateLimitRule, subject string) (domain.RateLimitResult, error) {
+	key := domain.TenantKey(domain.TenantFrom(ctx), "ratelimit:"+rule.Route+":"+string(rule.By)+":"+subject)
+
+	res, err := s.repo.Allow(ctx, key, rule.Limit, rule.Window, 1)
+	if err != nil {
+		// Лимитер не должен ронять проверки, когда Redis моргнул
+		slog.WarnContext(ctx, "rate limiter unavailable", "route", rule.Route, logging.Err(err))
+		return domain.RateLimitResult{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit}, nil
+	}
+
+	if !res.Allowed {
+		s.mu.Lock()
+		s.rejected[rule.Route+" "+string(rule.By)]++
+		s.mu.Unlock()
+		metrics.RateLimitRejections.WithLabelValues(rule.Route, string(rule.By)).Inc()
+	}
+	return res, nil
+}
+
+func (s *rateLimitService) Apply(ctx context.Context, route string, subject func(domain.RateLimitBy) string) (*domain.RateLimitResult, error) {
+	var tightest *domain.RateLimitResult
+	for _, rule := range s.rules[route] {
+		subj := subject(rule.By)
+		if subj == "" {
+			continue
+		}
+
+		res, err := s.Allow(ctx, rule, subj)
+		if err != nil {
+			return tightest, err
+		}
+		if !res.Allowed {
+			return &res, domain.NewRateLimitedError("rate limit exceeded: "+strconv.Itoa(rule.Limit)+" per "+rule.Window.String()+" by "+string(rule.By), res.RetryAfter)
+		}
+		if tightest == nil || res.Remaining < tightest.Remaining {
+			tightest = &res
+		}
+	}
+	return tightest, nil
+}
+
+func (s *rateLimitService) Rejections() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]int64, len(s.rejected))
+	for k, v := range s.rejected {
+		out[k] = v
+	}
+	return out
+}
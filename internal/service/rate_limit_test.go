@@ -0,0 +1,79 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	check := "POST /api/v1/location/check"
+	stream := "GET /api/v1/location/stream"
+
+	tests := []struct {
+		spec string
+		want []domain.RateLimitRule
+	}{
+		{"", nil},
+		{"  off ", nil},
+		{
+			"post /api/v1/location/check key=6000/1m user=600/1m",
+			[]domain.RateLimitRule{
+				{Route: check, By: domain.RateLimitByKey, Limit: 6000, Window: time.Minute},
+				{Route: check, By: domain.RateLimitByUser, Limit: 600, Window: time.Minute},
+			},
+		},
+		{
+			"POST /api/v1/location/check ip=10/30s; ; GET /api/v1/location/stream key=5/1h;",
+			[]domain.RateLimitRule{
+				{Route: check, By: domain.RateLimitByIP, Limit: 10, Window: 30 * time.Second},
+				{Route: stream, By: domain.RateLimitByKey, Limit: 5, Window: time.Hour},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRateLimits(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitsDefault(t *testing.T) {
+	rules, err := ParseRateLimits(DefaultRateLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 5 {
+		t.Errorf("default rules: got %d, want 5", len(rules))
+	}
+}
+
+func TestParseRateLimitsErrors(t *testing.T) {
+	for _, spec := range []string{
+		"POST /api/v1/location/check",
+		"POST /api/v1/location/check key",
+		"POST /api/v1/location/check tenant=10/1m",
+		"POST /api/v1/location/check key=10",
+		"POST /api/v1/location/check key=0/1m",
+		"POST /api/v1/location/check key=-1/1m",
+		"POST /api/v1/location/check key=ten/1m",
+		"POST /api/v1/location/check key=10/minute",
+		"POST /api/v1/location/check key=10/500ms",
+		"POST /api/v1/location/check key=10/1m; GET /stream",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			if rules, err := ParseRateLimits(spec); err == nil {
+				t.Errorf("want error, got %+v", rules)
+			}
+		})
+	}
+}
@@ -7,11 +7,28 @@ import (
 
 type Service struct {
 	domain.IncidentService
+	domain.APIKeyService
+	domain.TokenService
+	domain.TenantService
+	domain.RateLimitService
+
+	incidents *incidentService
 }
 
-func NewService(repos *repository.Repository, cfg IncidentConfig) *Service {
-	incidentService := NewIncidentService(repos.Incidents, repos.IncidentCashe, repos.Queues, cfg)
+func NewService(repos *repository.Repository, cfg IncidentConfig, authCfg AuthConfig, tokenCfg TokenConfig, rateLimits []domain.RateLimitRule) *Service {
+	incidents := NewIncidentService(repos.Incidents, repos.IncidentCashe, repos.Queues, repos.Events, repos.Audit, cfg)
 	return &Service{
-		IncidentService: incidentService,
+		IncidentService:  incidents,
+		APIKeyService:    NewAPIKeyService(repos.APIKeys, repos.APIKeyUsage, authCfg),
+		TokenService:     NewTokenService(tokenCfg),
+		TenantService:    NewTenantService(repos.Tenants, repos.RateLimits),
+		RateLimitService: NewRateLimitService(repos.RateLimits, rateLimits),
+
+		incidents: incidents.(*incidentService),
 	}
 }
+
+// UpdateIncidentConfig применяет новый радиус и окно статистики без рестарта
+func (s *Service) UpdateIncidentConfig(cfg IncidentConfig) {
+	s.incidents.updateConfig(cfg)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+)
+
+// tenantIDPattern — ID попадает в ключи Redis, поэтому без двоеточий и пробелов
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// tenantCacheTTL — настройки тенанта читаются на каждую проверку и вебхук, в базу ходим не чаще
+const tenantCacheTTL = 30 * time.Second
+
+type cachedTenant struct {
+	tenant   *domain.Tenant
+	loadedAt time.Time
+}
+
+type tenantService struct {
+	repo  domain.TenantRepository
+	rates domain.RateLimitRepository
+
+	mu    sync.RWMutex
+	cache map[string]cachedTenant
+}
+
+func NewTenantService(repo domain.TenantRepository, rates domain.RateLimitRepository) domain.TenantService {
+	return &tenantService{
+		repo:  repo,
+		rates: rates,
+		cache: make(map[string]cachedTenant),
+	}
+}
+
+func (s *tenantService) CreateTenant(ctx context.Context, input domain.CreateTenantInput) (*domain.Tenant, error) {
+	if !domain.PrincipalFrom(ctx).IsPlatformAdmin() {
+		return nil, domain.NewForbiddenError("only platform admin can manage tenants")
+	}
+	if !tenantIDPattern.MatchString(input.ID) {
+		return nil, domain.NewValidationError(domain.CodeValidation, "request validation failed",
+			domain.FieldError{Field: "id", Message: "must match " + tenantIDPattern.String()})
+	}
+
+	t := &domain.Tenant{
+		ID:         input.ID,
+		Name:       input.Name,
+		WebhookURL: input.WebhookURL,
+		RateLimit:  input.RateLimit,
+	}
+	if err := s.repo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "tenant created", "tenant_id", t.ID)
+	return t, nil
+}
+
+func (s *tenantService) GetTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[id]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < tenantCacheTTL {
+		return cached.tenant, nil
+	}
+
+	t, err := s.repo.Get(ctx, id)
+	if err != nil {
+		// База недоступна — лучше старые настройки, чем отказ
+		if ok {
+			slog.WarnContext(ctx, "failed to refresh tenant, using cached", "tenant_id", id, logging.Err(err))
+			return cached.tenant, nil
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[id] = cachedTenant{tenant: t, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return t, nil
+}
+
+func (s *tenantService) ListTenants(ctx context.Context) ([]domain.Tenant, error) {
+	if !domain.PrincipalFrom(ctx).IsPlatformAdmin() {
+		return nil, domain.NewForbiddenError("only platform admin can manage tenants")
+	}
+	return s.repo.List(ctx)
+}
+
+func (s *tenantService) UpdateTenant(ctx context.Context, id string, input domain.UpdateTenantInput) (*domain.Tenant, error) {
+	if !domain.PrincipalFrom(ctx).IsPlatformAdmin() {
+		return nil, domain.NewForbiddenError("only platform admin can manage tenants")
+	}
+
+	if input.WebhookURL != nil && *input.WebhookURL != "" {
+		if u, err := url.ParseRequestURI(*input.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, domain.NewValidationError(domain.CodeValidation, "request validation failed",
+				domain.FieldError{Field: "webhook_url", Message: "must be an http(s) url or empty"})
+		}
+	}
+
+	t, err := s.repo.Update(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Свой кэш обновляем сразу, остальные инстансы подхватят через tenantCacheTTL
+	s.mu.Lock()
+	s.cache[id] = cachedTenant{tenant: t, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return t, nil
+}
+
+func (s *tenantService) CheckRateLimit(ctx context.Context, checks int) error {
+	t, err := s.GetTenant(ctx, domain.TenantFrom(ctx))
+	if err != nil {
+		return err
+	}
+	if t.RateLimit <= 0 {
+		return nil
+	}
+	// Такой пакет не пройдет ни в одном окне, повтор не поможет
+	if checks > t.RateLimit {
+		return domain.NewValidationError(domain.CodeValidation, "batch exceeds tenant rate limit",
+			domain.FieldError{Field: "checks", Message: "must be at most " + strconv.Itoa(t.RateLimit) + " per minute for this tenant"})
+	}
+
+	res, err := s.rates.Allow(ctx, domain.TenantKey(t.ID, "ratelimit:location"), t.RateLimit, time.Minute, checks)
+	if err != nil {
+		// Лимитер не должен ронять проверки, когда Redis моргнул
+		slog.WarnContext(ctx, "tenant rate limiter unavailable", "tenant_id", t.ID, logging.Err(err))
+		return nil
+	}
+	if !res.Allowed {
+		return domain.NewRateLimitedError("tenant rate limit exceeded", res.RetryAfter)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// fakeTenantRepo отдает тенантов из памяти
+type fakeTenantRepo struct {
+	domain.TenantRepository
+	tenants map[string]*domain.Tenant
+}
+
+func (f *fakeTenantRepo) Get(_ context.Context, id string) (*domain.Tenant, error) {
+	t, ok := f.tenants[id]
+	if !ok {
+		return nil, domain.NewNotFoundError(domain.CodeNotFound, "tenant not found")
+	}
+	cp := *t
+	return &cp, nil
+}
+
+// fakeRates — счетчик по ключу без скользящего окна; err, если задан, — Redis недоступен
+type fakeRates struct {
+	mu     sync.Mutex
+	counts map[string]int
+	err    error
+}
+
+func (f *fakeRates) Allow(_ context.Context, key string, limit int, window time.Duration, cost int) (domain.RateLimitResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return domain.RateLimitResult{}, f.err
+	}
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	if f.counts[key]+cost > limit {
+		return domain.RateLimitResult{Limit: limit, RetryAfter: window}, nil
+	}
+	f.counts[key] += cost
+	return domain.RateLimitResult{Allowed: true, Limit: limit, Remaining: limit - f.counts[key]}, nil
+}
+
+func TestTenantCheckRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		rateErr error
+		checks  []int
+		want    []error // nil — проверки засчитаны
+	}{
+		{"within limit", 5, nil, []int{2, 3}, []error{nil, nil}},
+		{"over limit", 5, nil, []int{3, 3, 2}, []error{nil, domain.ErrRateLimited, nil}},
+		{"batch larger than limit", 5, nil, []int{6}, []error{domain.ErrValidation}},
+		{"no limit", 0, nil, []int{1000, 1000}, []error{nil, nil}},
+		{"limiter down", 1, errors.New("redis: connection refused"), []int{1, 1}, []error{nil, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTenantRepo{tenants: map[string]*domain.Tenant{"acme": {ID: "acme", RateLimit: tt.limit}}}
+			svc := NewTenantService(repo, &fakeRates{err: tt.rateErr})
+			ctx := domain.WithTenant(context.Background(), "acme")
+
+			for i, n := range tt.checks {
+				err := svc.CheckRateLimit(ctx, n)
+				if tt.want[i] == nil {
+					if err != nil {
+						t.Fatalf("call %d (%d checks): %v", i, n, err)
+					}
+					continue
+				}
+				if !errors.Is(err, tt.want[i]) {
+					t.Fatalf("call %d (%d checks): err = %v, want %v", i, n, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTenantRateLimitIsolated(t *testing.T) {
+	repo := &fakeTenantRepo{tenants: map[string]*domain.Tenant{
+		"acme":  {ID: "acme", RateLimit: 2},
+		"other": {ID: "other", RateLimit: 2},
+	}}
+	svc := NewTenantService(repo, &fakeRates{})
+	acme := domain.WithTenant(context.Background(), "acme")
+	other := domain.WithTenant(context.Background(), "other")
+
+	if err := svc.CheckRateLimit(acme, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.CheckRateLimit(acme, 1); !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("acme over limit: err = %v, want rate limited", err)
+	}
+	// Исчерпанный лимит одного тенанта не задевает другого
+	if err := svc.CheckRateLimit(other, 2); err != nil {
+		t.Errorf("other: %v", err)
+	}
+}
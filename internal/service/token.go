@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type TokenConfig struct {
+	JWKS       string // путь к файлу или URL набора ключей, пусто — токены выключены
+	Issuer     string // ожидаемый iss, пусто — не проверять
+	Audience   string // ожидаемый aud, пусто — не проверять
+	RolesClaim string // где лежат роли, через точку: "roles", "realm_access.roles"
+	// TenantClaim — claim с ID тенанта оператора, токен без него отвергается
+	TenantClaim string
+	// JWKSRefresh — как часто перечитывать ключи, чтобы подхватить ротацию
+	JWKSRefresh time.Duration
+}
+
+type tokenService struct {
+	cfg    TokenConfig
+	jwks   *jwksSource
+	parser *jwt.Parser
+}
+
+func NewTokenService(cfg TokenConfig) domain.TokenService {
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant_id"
+	}
+	if cfg.JWKSRefresh <= 0 {
+		cfg.JWKSRefresh = 10 * time.Minute
+	}
+
+	opts := []jwt.ParserOption{
+		// Только асимметричные алгоритмы: HS256 с публичным ключом в роли секрета — известная дыра
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	s := &tokenService{cfg: cfg, parser: jwt.NewParser(opts...)}
+	if cfg.JWKS != "" {
+		s.jwks = newJWKSSource(cfg.JWKS, cfg.JWKSRefresh)
+	}
+	return s
+}
+
+func (s *tokenService) Enabled() bool {
+	return s.jwks != nil
+}
+
+func (s *tokenService) VerifyToken(ctx context.Context, rawToken string) (*domain.Principal, error) {
+	if !s.Enabled() {
+		return nil, domain.NewUnauthorizedError("bearer tokens are not accepted")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := s.parser.ParseWithClaims(rawToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.jwks.key(ctx, kid)
+	})
+	if err != nil {
+		// Недоступный JWKS — не вина клиента
+		if errors.Is(err, domain.ErrUnavailable) {
+			return nil, err
+		}
+		return nil, domain.NewUnauthorizedError("invalid bearer token")
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, domain.NewUnauthorizedError("token has no subject")
+	}
+
+	// Без тенанта не подставляем default: admin тенанта хостинга — это admin платформы,
+	// такое право должно быть выписано в токене явно
+	tenantID, _ := claims[s.cfg.TenantClaim].(string)
+	if tenantID == "" {
+		return nil, domain.NewUnauthorizedError("token has no " + s.cfg.TenantClaim + " claim")
+	}
+
+	return &domain.Principal{
+		ID:       "user:" + sub,
+		Name:     operatorName(claims, sub),
+		Kind:     domain.PrincipalOperator,
+		TenantID: tenantID,
+		Scopes:   rolesToScopes(roleClaim(claims, s.cfg.RolesClaim)),
+	}, nil
+}
+
+// operatorName — что показать в аудите: логин, почта или хотя бы sub
+func operatorName(claims jwt.MapClaims, sub string) string {
+	for _, field := range []string{"preferred_username", "email", "name"} {
+		if v, ok := claims[field].(string); ok && v != "" {
+			return v
+		}
+	}
+	return sub
+}
+
+// roleClaim достает роли по пути через точку. Провайдеры отдают их массивом или строкой через пробел
+func roleClaim(claims jwt.MapClaims, path string) []string {
+	var cur interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+
+	switch v := cur.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
+
+func rolesToScopes(roles []string) []domain.Scope {
+	seen := make(map[domain.Scope]bool)
+	var scopes []domain.Scope
+	for _, role := range roles {
+		for _, scope := range domain.RoleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.test"
+	testAudience = "redgo"
+)
+
+// testSigner — ключ, которым тест подписывает токены, и его запись в JWKS
+type testSigner struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+func newRSASigner(t *testing.T, kid string) testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{kid: kid, method: jwt.SigningMethodRS256, key: key}
+}
+
+func (s testSigner) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": s.kid, "use": "sig", "n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": s.kid, "crv": "P-256", "x": b64(pub.X.FillBytes(make([]byte, 32))), "y": b64(pub.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": s.kid, "crv": "Ed25519", "x": b64(pub)}
+	}
+	panic("unsupported key")
+}
+
+func (s testSigner) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(s.method, claims)
+	tok.Header["kid"] = s.kid
+	raw, err := tok.SignedString(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// writeJWKS кладет набор ключей в файл — так же JWKS_URL может указывать на файл
+func writeJWKS(t *testing.T, signers ...testSigner) string {
+	t.Helper()
+	keys := make([]map[string]string, 0, len(signers))
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":                "u-1",
+		"preferred_username": "alice",
+		"iss":                testIssuer,
+		"aud":                testAudience,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"tenant_id":          "acme",
+		"roles":              []string{"operator"},
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	signer := newRSASigner(t, "k1")
+	svc := NewTokenService(TokenConfig{JWKS: writeJWKS(t, signer), Issuer: testIssuer, Audience: testAudience})
+
+	p, err := svc.VerifyToken(context.Background(), signer.sign(t, validClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "user:u-1" || p.Name != "alice" || p.Kind != domain.PrincipalOperator || p.TenantID != "acme" {
+		t.Errorf("principal = %+v", p)
+	}
+	if !p.HasScope(domain.ScopeIncidentsWrite) || p.HasScope(domain.ScopeAdmin) {
+		t.Errorf("scopes = %v, want operator scopes", p.Scopes)
+	}
+}
+
+func TestVerifyTokenRejects(t *testing.T) {
+	signer := newRSASigner(t, "k1")
+	stranger := newRSASigner(t, "k2")
+	svc := NewTokenService(TokenConfig{JWKS: writeJWKS(t, signer), Issuer: testIssuer, Audience: testAudience})
+
+	with := func(key string, value any) jwt.MapClaims {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", signer.sign(t, with("exp", time.Now().Add(-time.Hour).Unix()))},
+		{"no exp", signer.sign(t, with("exp", nil))},
+		{"wrong audience", signer.sign(t, with("aud", "other"))},
+		{"wrong issuer", signer.sign(t, with("iss", "https://evil.test"))},
+		{"unknown kid", stranger.sign(t, validClaims())},
+		{"no subject", signer.sign(t, with("sub", nil))},
+		{"no tenant claim", signer.sign(t, with("tenant_id", nil))},
+		{"empty tenant claim", signer.sign(t, with("tenant_id", ""))},
+		{"garbage", "not.a.token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := svc.VerifyToken(context.Background(), tt.token)
+			if !errors.Is(err, domain.ErrUnauthorized) {
+				t.Fatalf("err = %v (principal %+v), want unauthorized", err, p)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenNoImplicitPlatformAdmin(t *testing.T) {
+	signer := newRSASigner(t, "k1")
+	svc := NewTokenService(TokenConfig{JWKS: writeJWKS(t, signer)})
+
+	// admin без тенанта не становится admin платформы
+	claims := validClaims()
+	delete(claims, "tenant_id")
+	claims["roles"] = []string{"admin"}
+	if p, err := svc.VerifyToken(context.Background(), signer.sign(t, claims)); err == nil {
+		t.Fatalf("token without tenant accepted: %+v", p)
+	}
+
+	// Явно выписанный default — admin платформы
+	claims["tenant_id"] = domain.DefaultTenantID
+	p, err := svc.VerifyToken(context.Background(), signer.sign(t, claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsPlatformAdmin() {
+		t.Errorf("principal %+v should be platform admin", p)
+	}
+}
+
+func TestVerifyTokenCustomClaims(t *testing.T) {
+	signer := newRSASigner(t, "k1")
+	svc := NewTokenService(TokenConfig{JWKS: writeJWKS(t, signer), RolesClaim: "realm_access.roles", TenantClaim: "org"})
+
+	claims := validClaims()
+	delete(claims, "roles")
+	delete(claims, "tenant_id")
+	claims["realm_access"] = map[string]any{"roles": []string{"viewer", "unknown"}}
+	claims["org"] = "acme"
+
+	p, err := svc.VerifyToken(context.Background(), signer.sign(t, claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TenantID != "acme" || len(p.Scopes) != 1 || p.Scopes[0] != domain.ScopeIncidentsRead {
+		t.Errorf("principal = %+v, want acme viewer", p)
+	}
+}
+
+func TestVerifyTokenRejectsHMAC(t *testing.T) {
+	signer := newRSASigner(t, "k1")
+	svc := NewTokenService(TokenConfig{JWKS: writeJWKS(t, signer)})
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	tok.Header["kid"] = "k1"
+	raw, err := tok.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.VerifyToken(context.Background(), raw); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("err = %v, want unauthorized", err)
+	}
+}
+
+func TestVerifyTokenDisabled(t *testing.T) {
+	svc := NewTokenService(TokenConfig{})
+	if svc.Enabled() {
+		t.Fatal("token service without JWKS must be disabled")
+	}
+	if _, err := svc.VerifyToken(context.Background(), "x"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("err = %v, want unauthorized", err)
+	}
+}
+
+func TestJWKSKeyTypes(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signers := []testSigner{
+		newRSASigner(t, "rsa"),
+		{kid: "ec", method: jwt.SigningMethodES256, key: ecKey},
+		{kid: "ed", method: jwt.SigningMethodEdDSA, key: edKey},
+	}
+	svc := NewTokenService(TokenConfig{JWKS: writeJWKS(t, signers...)})
+
+	for _, s := range signers {
+		t.Run(s.kid, func(t *testing.T) {
+			if _, err := svc.VerifyToken(context.Background(), s.sign(t, validClaims())); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestJWKSOverHTTP(t *testing.T) {
+	old := newRSASigner(t, "old")
+	rotated := newRSASigner(t, "new")
+
+	var current atomic.Value
+	current.Store([]testSigner{old})
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var keys []map[string]string
+		for _, s := range current.Load().([]testSigner) {
+			keys = append(keys, s.jwk())
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+
+	source := newJWKSSource(srv.URL, time.Hour)
+	ctx := context.Background()
+
+	if _, err := source.key(ctx, "old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.key(ctx, "old"); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("jwks fetched %d times, want 1 within refresh interval", n)
+	}
+
+	// Неизвестный kid сразу после загрузки не дергает провайдер повторно
+	current.Store([]testSigner{old, rotated})
+	if _, err := source.key(ctx, "new"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("err = %v, want unauthorized for unknown kid", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("jwks fetched %d times, want miss refresh to be throttled", n)
+	}
+
+	// После jwksMissRefresh новый ключ подхватывается
+	source.mu.Lock()
+	source.attemptedAt = time.Now().Add(-2 * jwksMissRefresh)
+	source.mu.Unlock()
+	if _, err := source.key(ctx, "new"); err != nil {
+		t.Errorf("rotated key not picked up: %v", err)
+	}
+}
+
+func TestJWKSUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	signer := newRSASigner(t, "k1")
+	svc := NewTokenService(TokenConfig{JWKS: srv.URL})
+
+	// Недоступный провайдер — 503, а не 401: клиент тут ни при чем
+	if _, err := svc.VerifyToken(context.Background(), signer.sign(t, validClaims())); !errors.Is(err, domain.ErrUnavailable) {
+		t.Errorf("err = %v, want unavailable", err)
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queuePendingDesc = prometheus.NewDesc("redgo_webhook_queue_pending", "Webhook tasks waiting in the queue, all tenants.", nil, nil)
+	queueDelayedDesc = prometheus.NewDesc("redgo_webhook_queue_delayed", "Webhook tasks waiting for a retry, all tenants.", nil, nil)
+	queueDLQDesc     = prometheus.NewDesc("redgo_webhook_queue_dlq", "Webhook tasks in the DLQ, all tenants.", nil, nil)
+	activeDesc       = prometheus.NewDesc("redgo_webhook_workers_active", "Webhook workers started by the autoscaler.", nil, nil)
+)
+
+// Collector отдает размеры очередей и число воркеров, читая их из Redis в момент scrape
+func (w *WebhookWorker) Collector() prometheus.Collector {
+	return &queueCollector{w: w}
+}
+
+type queueCollector struct {
+	w *WebhookWorker
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queuePendingDesc
+	ch <- queueDelayedDesc
+	ch <- queueDLQDesc
+	ch <- activeDesc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	stats, err := c.w.GetStats(ctx)
+	if err != nil {
+		// Без Redis очереди не отдаем вовсе: нулевой размер выглядел бы как пустая очередь
+		slog.Warn("failed to collect queue metrics", logging.Err(err))
+	} else {
+		ch <- prometheus.MustNewConstMetric(queuePendingDesc, prometheus.GaugeValue, float64(stats.PendingTasks))
+		ch <- prometheus.MustNewConstMetric(queueDelayedDesc, prometheus.GaugeValue, float64(stats.DelayedTasks))
+		ch <- prometheus.MustNewConstMetric(queueDLQDesc, prometheus.GaugeValue, float64(stats.DLQTasks))
+	}
+	ch <- prometheus.MustNewConstMetric(activeDesc, prometheus.GaugeValue, float64(atomic.LoadInt32(&c.w.activeWorkers)))
+}
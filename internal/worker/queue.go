@@ -6,16 +6,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/metrics"
+	"github.com/ArtemChadaev/RedGo/internal/tracing"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var transferScript = redis.NewScript(`
@@ -31,9 +37,15 @@ var transferScript = redis.NewScript(`
 
 type WebhookWorker struct {
 	redis      *redis.Client
-	webhookURL string
+	webhookURL atomic.Pointer[string] // общий адрес для тенантов без своего webhook_url, меняется на лету
+	tenants    domain.TenantService
 	client     *http.Client
 
+	// Тенанты с очередями, перечитываются из WebhookTenantsKey раз в tenantsRefresh
+	tenantsMu       sync.RWMutex
+	tenantIDs       []string
+	tenantsLoadedAt time.Time
+
 	// Поля для автоскейлинга
 	activeWorkers int32                // Атомарный счетчик живых воркеров
 	workerCancel  []context.CancelFunc // Функции для остановки лишних воркеров
@@ -42,12 +54,16 @@ type WebhookWorker struct {
 	wg sync.WaitGroup
 }
 
-func NewWebhookWorker(redis *redis.Client, url string) *WebhookWorker {
-	return &WebhookWorker{
-		redis:      redis,
-		webhookURL: url,
+// tenantsRefresh — как быстро воркер замечает очередь нового тенанта
+const tenantsRefresh = 5 * time.Second
+
+// timeout — предел на один запрос к получателю, зависший получатель не держит воркер дольше
+func NewWebhookWorker(redis *redis.Client, url string, timeout time.Duration, tenants domain.TenantService) *WebhookWorker {
+	w := &WebhookWorker{
+		redis:   redis,
+		tenants: tenants,
 		client: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout: timeout,
 			Transport: &http.Transport{
 				MaxIdleConns:        500,
 				IdleConnTimeout:     90 * time.Second,
@@ -55,6 +71,13 @@ func NewWebhookWorker(redis *redis.Client, url string) *WebhookWorker {
 			},
 		},
 	}
+	w.webhookURL.Store(&url)
+	return w
+}
+
+// SetWebhookURL меняет общий адрес вебхуков при перезагрузке конфига. Задачи в полете доставляются по старому
+func (w *WebhookWorker) SetWebhookURL(url string) {
+	w.webhookURL.Store(&url)
 }
 
 // StartAutoscaler — ЕДИНСТВЕННАЯ точка входа. Сама управляет мощностью.
@@ -62,7 +85,7 @@ func (w *WebhookWorker) StartAutoscaler(ctx context.Context, min, max int) {
 	w.wg.Add(1)
 	defer w.wg.Done()
 
-	log.Printf("Autoscaler started. Min: %d, Max: %d", min, max)
+	slog.Info("autoscaler started", "min", min, "max", max)
 
 	// Запускаем минимальное кол-во воркеров сразу
 	for i := 0; i < min; i++ {
@@ -77,7 +100,7 @@ func (w *WebhookWorker) StartAutoscaler(ctx context.Context, min, max int) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			qLen, err := w.redis.LLen(ctx, domain.WebhookQueueKey).Result()
+			qLen, err := w.queueLen(ctx, domain.WebhookQueueKey)
 			if err != nil {
 				continue
 			}
@@ -103,7 +126,8 @@ func (w *WebhookWorker) StartAutoscaler(ctx context.Context, min, max int) {
 			// 3. МАСШТАБИРУЕМ ПАЧКОЙ (БОЛЬШЕ ЗА РАЗ)
 			if target > current {
 				diff := target - current
-				log.Printf("Scaling UP: +%d workers (Queue: %d, Total: %d)", diff, qLen, target)
+				slog.Info("scaling up", "added", diff, "queue", qLen, "workers", target)
+				metrics.ScalingEvents.WithLabelValues("up").Inc()
 				for i := 0; i < int(diff); i++ {
 					w.addWorker(ctx)
 				}
@@ -113,7 +137,8 @@ func (w *WebhookWorker) StartAutoscaler(ctx context.Context, min, max int) {
 				if diff > 5 {
 					diff = 5
 				}
-				log.Printf("Scaling DOWN: -%d workers (Target: %d)", diff, target)
+				slog.Info("scaling down", "removed", diff, "queue", qLen, "target", target)
+				metrics.ScalingEvents.WithLabelValues("down").Inc()
 				for i := 0; i < int(diff); i++ {
 					w.removeWorker()
 				}
@@ -153,43 +178,84 @@ func (w *WebhookWorker) removeWorker() {
 }
 
 func (w *WebhookWorker) runWorkerLoop(ctx context.Context, id int32) {
-	log.Printf("Worker #%d started", id)
+	slog.Debug("worker started", "worker", id)
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Worker #%d stopped", id)
+			slog.Debug("worker stopped", "worker", id)
 			return
 		default:
-			result, err := w.redis.BLPop(ctx, 5*time.Second, domain.WebhookQueueKey).Result()
+			// Порядок ключей перемешиваем: BLPOP берет из первой непустой очереди,
+			// и без этого один тенант с большой очередью задерживал бы остальных
+			keys := w.tenantKeys(ctx, domain.WebhookQueueKey)
+			rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+
+			result, err := w.redis.BLPop(ctx, 5*time.Second, keys...).Result()
 			if err != nil {
 				continue // Здесь BLPop прервется сам, если вызвать cancel() контекста
 			}
 
 			var task domain.WebhookTask
 			if err := json.Unmarshal([]byte(result[1]), &task); err != nil {
-				log.Printf("CRITICAL: poison pill in queue! Failed to unmarshal: %v. Data: %s", err, result[1])
+				slog.Error("poison pill in queue, dropping task", "queue", result[0], "data", result[1], logging.Err(err))
 				continue // Пропускаем битую задачу и идем за следующей
 			}
 
-			if err := w.processTask(ctx, task); err != nil {
-				w.handleFailure(task)
+			// ID запроса и доставки попадают во все логи задачи, в том числе при повторах
+			taskCtx := logging.WithDeliveryID(logging.WithRequestID(ctx, task.RequestID), task.DeliveryID)
+			if err := w.processTask(taskCtx, task); err != nil {
+				w.handleFailure(taskCtx, task, err)
 			}
 		}
 	}
 }
 
-// processTask выполняет непосредственную отправку HTTP POST запроса
-func (w *WebhookWorker) processTask(ctx context.Context, task domain.WebhookTask) error {
-	body, _ := json.Marshal(task)
+// processTask выполняет непосредственную отправку HTTP POST запроса.
+// Спан продолжает трейс проверки из задачи, traceparent уходит получателю
+func (w *WebhookWorker) processTask(ctx context.Context, task domain.WebhookTask) (err error) {
+	ctx, span := tracing.Start(tracing.Extract(ctx, task.TraceContext), "WebhookWorker.processTask",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.Int("incident_id", task.IncidentID),
+			attribute.String("tenant_id", task.TenantID),
+			attribute.Int("retries", task.Retries),
+		))
+	defer func() { tracing.End(span, err) }()
+
+	// Получателю уходит только тело: служебные поля задачи остаются в очереди, ID доставки — в заголовках
+	body, err := json.Marshal(task.WebhookPayload)
+	if err != nil {
+		return fmt.Errorf("payload encode error: %w", err)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", w.webhookURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", w.webhookURLFor(ctx, task.TenantID), bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("request build error: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	// Повторы идут с тем же X-Delivery-ID, по нему получатель отсеет дубли
+	req.Header.Set("X-Delivery-ID", task.DeliveryID)
+	req.Header.Set("X-Delivery-Attempt", strconv.Itoa(task.Retries+1))
+	// Общий WEBHOOK_URL принимает вебхуки нескольких тенантов
+	if task.TenantID != "" {
+		req.Header.Set("X-Tenant-ID", task.TenantID)
+	}
+	if task.RequestID != "" {
+		req.Header.Set("X-Request-ID", task.RequestID)
+	}
+	tracing.InjectHTTP(ctx, req.Header)
 
+	start := time.Now()
 	resp, err := w.client.Do(req)
+	code := 0
+	if err == nil {
+		code = resp.StatusCode
+	}
+	class := metrics.StatusClass(code)
+	span.SetAttributes(attribute.Int("http.response.status_code", code))
+	metrics.WebhookDeliveries.WithLabelValues(class).Inc()
+	metrics.WebhookDuration.WithLabelValues(class).Observe(time.Since(start).Seconds())
 	if err != nil {
 		return fmt.Errorf("network error: %w", err)
 	}
@@ -199,12 +265,83 @@ func (w *WebhookWorker) processTask(ctx context.Context, task domain.WebhookTask
 		return fmt.Errorf("server error: status %d", resp.StatusCode)
 	}
 
-	log.Printf("Successfully sent webhook for incident %d (User %d)", task.IncidentID, task.UserID)
+	slog.DebugContext(ctx, "webhook delivered", "incident_id", task.IncidentID, "user_id", task.UserID, "tenant_id", task.TenantID, "status", resp.StatusCode)
 	return nil
 }
 
-// handleFailure обрабатывает ошибки: планирует пере повтор (ZSet) или отправляет в DLQ
-func (w *WebhookWorker) handleFailure(task domain.WebhookTask) {
+// webhookURLFor — адрес вебхука тенанта или общий, если свой не задан или настройки недоступны
+func (w *WebhookWorker) webhookURLFor(ctx context.Context, tenantID string) string {
+	if tenantID == "" {
+		tenantID = domain.DefaultTenantID
+	}
+
+	t, err := w.tenants.GetTenant(ctx, tenantID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load tenant, using default webhook url", "tenant_id", tenantID, logging.Err(err))
+		return *w.webhookURL.Load()
+	}
+	if t.WebhookURL == "" {
+		return *w.webhookURL.Load()
+	}
+	return t.WebhookURL
+}
+
+// tenantKeys — ключ key в пространстве каждого тенанта, у которого бывают задачи
+func (w *WebhookWorker) tenantKeys(ctx context.Context, key string) []string {
+	w.tenantsMu.RLock()
+	ids, loadedAt := w.tenantIDs, w.tenantsLoadedAt
+	w.tenantsMu.RUnlock()
+
+	if ids == nil || time.Since(loadedAt) > tenantsRefresh {
+		fresh, err := w.redis.SMembers(ctx, domain.WebhookTenantsKey).Result()
+		if err != nil {
+			slog.Warn("failed to load webhook tenants", logging.Err(err))
+		} else {
+			ids = []string{domain.DefaultTenantID}
+			for _, id := range fresh {
+				if id != domain.DefaultTenantID {
+					ids = append(ids, id)
+				}
+			}
+			w.tenantsMu.Lock()
+			w.tenantIDs, w.tenantsLoadedAt = ids, time.Now()
+			w.tenantsMu.Unlock()
+		}
+	}
+	if ids == nil {
+		ids = []string{domain.DefaultTenantID}
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, domain.TenantKey(id, key))
+	}
+	return keys
+}
+
+// queueLen — суммарная длина списков key по всем тенантам
+func (w *WebhookWorker) queueLen(ctx context.Context, key string) (int64, error) {
+	keys := w.tenantKeys(ctx, key)
+
+	pipe := w.redis.Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	for _, k := range keys {
+		cmds = append(cmds, pipe.LLen(ctx, k))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, cmd := range cmds {
+		total += cmd.Val()
+	}
+	return total, nil
+}
+
+// handleFailure обрабатывает ошибки: планирует пере повтор (ZSet) или отправляет в DLQ.
+// ctx нужен только для логов
+func (w *WebhookWorker) handleFailure(ctx context.Context, task domain.WebhookTask, cause error) {
 	task.Retries++
 
 	// ВАЖНО: Создаем новый контекст на 2 секунды для финальной записи в Redis.
@@ -213,11 +350,12 @@ func (w *WebhookWorker) handleFailure(task domain.WebhookTask) {
 	defer cancel()
 
 	if task.Retries >= domain.MaxRetries {
-		log.Printf("Task %d FAILED after %d attempts. Moving to DLQ.", task.IncidentID, domain.MaxRetries)
+		slog.ErrorContext(ctx, "webhook failed after last attempt, moving to DLQ", "incident_id", task.IncidentID, "attempts", domain.MaxRetries, logging.Err(cause))
+		metrics.WebhookDeadLettered.Inc()
 		data, _ := json.Marshal(task)
 		// Используем cleanupCtx вместо ctx
-		if err := w.redis.RPush(cleanupCtx, domain.WebhookDLQKey, data).Err(); err != nil {
-			log.Printf("Critical: failed to push to DLQ: %v", err)
+		if err := w.redis.RPush(cleanupCtx, domain.TenantKey(task.TenantID, domain.WebhookDLQKey), data).Err(); err != nil {
+			slog.ErrorContext(ctx, "failed to push task to DLQ, task lost", "incident_id", task.IncidentID, logging.Err(err))
 		}
 		return
 	}
@@ -238,15 +376,16 @@ func (w *WebhookWorker) handleFailure(task domain.WebhookTask) {
 
 	data, _ := json.Marshal(task)
 
-	err := w.redis.ZAdd(cleanupCtx, "webhooks:delayed", redis.Z{
+	err := w.redis.ZAdd(cleanupCtx, domain.TenantKey(task.TenantID, domain.WebhookDelayedKey), redis.Z{
 		Score:  float64(executeAt),
 		Member: data,
 	}).Err()
 
 	if err != nil {
-		log.Printf("Failed to schedule retry for task %d: %v", task.IncidentID, err)
+		slog.ErrorContext(ctx, "failed to schedule retry, task lost", "incident_id", task.IncidentID, logging.Err(err))
 	} else {
-		log.Printf("Task %d scheduled for retry #%d in %v", task.IncidentID, task.Retries, delay)
+		metrics.WebhookRetries.Inc()
+		slog.WarnContext(ctx, "webhook failed, retry scheduled", "incident_id", task.IncidentID, "retry", task.Retries, "delay", delay, logging.Err(cause))
 	}
 }
 
@@ -255,35 +394,53 @@ func (w *WebhookWorker) RunScheduler(ctx context.Context) {
 	w.wg.Add(1)
 	defer w.wg.Done()
 
-	log.Println("Scheduler started (ZSet -> Queue)")
+	slog.Info("scheduler started")
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Stopping scheduler...")
+			slog.Info("scheduler stopped")
 			return
 		case <-ticker.C:
 			// Запускаем Lua-скрипт.
 			// Переносим все задачи, чей Score (время) <= текущему времени
 			now := time.Now().Unix()
 
-			// KEYS[1] = webhooks:delayed, KEYS[2] = webhooks:queue, ARGV[1] = now
-			count, err := transferScript.Run(ctx, w.redis,
-				[]string{"webhooks:delayed", domain.WebhookQueueKey},
-				now,
-			).Int()
-
-			if err != nil && !errors.Is(err, redis.Nil) {
-				log.Printf("Scheduler Lua error: %v", err)
-			} else if count > 0 {
-				log.Printf("Scheduler: moved %d tasks to main queue", count)
+			// У каждого тенанта своя пара ZSet -> очередь
+			for _, tenantID := range w.tenantIDsSnapshot(ctx) {
+				// KEYS[1] = webhooks:delayed, KEYS[2] = webhooks:queue, ARGV[1] = now
+				count, err := transferScript.Run(ctx, w.redis,
+					[]string{
+						domain.TenantKey(tenantID, domain.WebhookDelayedKey),
+						domain.TenantKey(tenantID, domain.WebhookQueueKey),
+					},
+					now,
+				).Int()
+
+				if err != nil && !errors.Is(err, redis.Nil) {
+					slog.Error("scheduler failed to move delayed tasks", "tenant_id", tenantID, logging.Err(err))
+				} else if count > 0 {
+					slog.Debug("scheduler moved delayed tasks to queue", "tenant_id", tenantID, "count", count)
+				}
 			}
 		}
 	}
 }
 
+// tenantIDsSnapshot — ID тенантов с очередями, default всегда первым
+func (w *WebhookWorker) tenantIDsSnapshot(ctx context.Context) []string {
+	w.tenantKeys(ctx, domain.WebhookQueueKey) // обновляет список, если он устарел
+
+	w.tenantsMu.RLock()
+	defer w.tenantsMu.RUnlock()
+	if w.tenantIDs == nil {
+		return []string{domain.DefaultTenantID}
+	}
+	return w.tenantIDs
+}
+
 func (w *WebhookWorker) Wait() {
 	w.wg.Wait()
 }
@@ -292,26 +449,37 @@ func (w *WebhookWorker) Wait() {
 type Stats struct {
 	PendingTasks  int64 `json:"pending_tasks"`  // В основной очереди
 	DelayedTasks  int64 `json:"delayed_tasks"`  // На повторе (ZSet)
+	DLQTasks      int64 `json:"dlq_tasks"`      // Исчерпали попытки
 	ActiveWorkers int32 `json:"active_workers"` // Живые горутины
 }
 
 func (w *WebhookWorker) GetStats(ctx context.Context) (Stats, error) {
-	// 1. Сколько задач ждут прямо сейчас
-	qLen, err := w.redis.LLen(ctx, domain.WebhookQueueKey).Result()
+	// 1. Сколько задач ждут прямо сейчас, по всем тенантам
+	qLen, err := w.queueLen(ctx, domain.WebhookQueueKey)
 	if err != nil {
 		return Stats{}, fmt.Errorf("failed to get queue len: %w", err)
 	}
 
-	// 2. Сколько задач "спят" и ждут времени переповтора.
-	// Используем константу или строку "webhooks:delayed"
-	dLen, err := w.redis.ZCard(ctx, "webhooks:delayed").Result()
+	// 2. Сколько задач "спят" и ждут времени переповтора
+	var dLen int64
+	for _, key := range w.tenantKeys(ctx, domain.WebhookDelayedKey) {
+		n, err := w.redis.ZCard(ctx, key).Result()
+		if err != nil {
+			return Stats{}, fmt.Errorf("failed to get delayed len: %w", err)
+		}
+		dLen += n
+	}
+
+	// 3. Сколько задач исчерпали попытки
+	dlqLen, err := w.queueLen(ctx, domain.WebhookDLQKey)
 	if err != nil {
-		return Stats{}, fmt.Errorf("failed to get delayed len: %w", err)
+		return Stats{}, fmt.Errorf("failed to get dlq len: %w", err)
 	}
 
 	return Stats{
 		PendingTasks:  qLen,
 		DelayedTasks:  dLen,
+		DLQTasks:      dlqLen,
 		ActiveWorkers: atomic.LoadInt32(&w.activeWorkers),
 	}, nil
 }
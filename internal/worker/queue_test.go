@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+)
+
+// fakeTenants отдает всем тенантам webhookURL; err, если задан, — настройки недоступны
+type fakeTenants struct {
+	domain.TenantService
+	webhookURL string
+	err        error
+}
+
+func (f fakeTenants) GetTenant(_ context.Context, id string) (*domain.Tenant, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &domain.Tenant{ID: id, WebhookURL: f.webhookURL}, nil
+}
+
+func TestProcessTaskSendsOnlyPayload(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	w := NewWebhookWorker(nil, srv.URL, time.Second, fakeTenants{})
+	task := domain.WebhookTask{
+		WebhookPayload: domain.WebhookPayload{IncidentID: 7, UserID: 42, MapID: "arena", X: 1.5, Y: -2, Distance: 3},
+		TenantID:       "acme",
+		Retries:        2,
+		DeliveryID:     "d-1",
+		RequestID:      "r-1",
+		TraceContext:   map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}
+	if err := w.processTask(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	want := map[string]any{"incident_id": 7.0, "user_id": 42.0, "map_id": "arena", "x": 1.5, "y": -2.0, "distance": 3.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+
+	for name, want := range map[string]string{
+		"X-Delivery-ID":      "d-1",
+		"X-Delivery-Attempt": "3",
+		"X-Request-ID":       "r-1",
+		"X-Tenant-ID":        "acme",
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWebhookTaskQueueFormat(t *testing.T) {
+	// Задачи, положенные в очередь до разделения тела и конверта, должны читаться
+	raw := `{"tenant_id":"acme","incident_id":7,"user_id":42,"map_id":"arena","x":1,"y":2,"distance":3,"retries":1,"delivery_id":"d-1"}`
+
+	var task domain.WebhookTask
+	if err := json.Unmarshal([]byte(raw), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.IncidentID != 7 || task.UserID != 42 || task.TenantID != "acme" || task.Retries != 1 || task.DeliveryID != "d-1" {
+		t.Errorf("task = %+v", task)
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again domain.WebhookTask
+	if err := json.Unmarshal(data, &again); err != nil || !reflect.DeepEqual(again, task) {
+		t.Errorf("round trip: %+v, %v", again, err)
+	}
+}
+
+func TestWebhookURLFor(t *testing.T) {
+	const shared = "http://shared.example/hook"
+
+	tests := []struct {
+		name    string
+		tenants fakeTenants
+		want    string
+	}{
+		{"tenant url", fakeTenants{webhookURL: "https://acme.example/hook"}, "https://acme.example/hook"},
+		{"tenant without url", fakeTenants{}, shared},
+		{"tenant settings unavailable", fakeTenants{webhookURL: "https://acme.example/hook", err: domain.NewUnavailableError("database unavailable", errors.New("dial tcp"))}, shared},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWebhookWorker(nil, shared, time.Second, tt.tenants)
+			if got := w.webhookURLFor(context.Background(), "acme"); got != tt.want {
+				t.Errorf("url = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessTaskUsesTenantWebhook(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]string)
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			hits[name] = r.Header.Get("X-Tenant-ID")
+		}
+	}
+	shared := httptest.NewServer(handler("shared"))
+	defer shared.Close()
+	tenant := httptest.NewServer(handler("tenant"))
+	defer tenant.Close()
+
+	w := NewWebhookWorker(nil, shared.URL, time.Second, fakeTenants{webhookURL: tenant.URL})
+	task := domain.WebhookTask{WebhookPayload: domain.WebhookPayload{IncidentID: 1, UserID: 2}, TenantID: "acme", DeliveryID: "d-1"}
+	if err := w.processTask(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := hits["shared"]; ok {
+		t.Error("tenant delivery went to the shared webhook")
+	}
+	if got := hits["tenant"]; got != "acme" {
+		t.Errorf("tenant webhook got X-Tenant-ID %q, want acme", got)
+	}
+}
+
+func TestTenantQueueKeys(t *testing.T) {
+	tests := []struct {
+		tenantID string
+		key      string
+		want     string
+	}{
+		// Очереди тенанта по умолчанию остаются на старых ключах, задачи до разделения не теряются
+		{"", domain.WebhookQueueKey, domain.WebhookQueueKey},
+		{domain.DefaultTenantID, domain.WebhookDLQKey, domain.WebhookDLQKey},
+		{"acme", domain.WebhookQueueKey, "tenant:acme:" + domain.WebhookQueueKey},
+		{"acme", domain.WebhookDelayedKey, "tenant:acme:" + domain.WebhookDelayedKey},
+	}
+
+	for _, tt := range tests {
+		if got := domain.TenantKey(tt.tenantID, tt.key); got != tt.want {
+			t.Errorf("TenantKey(%q, %q) = %q, want %q", tt.tenantID, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ArtemChadaev/RedGo/internal/domain"
+	"github.com/ArtemChadaev/RedGo/internal/logging"
+	"github.com/ArtemChadaev/RedGo/internal/metrics"
+)
+
+const (
+	// retentionInterval — как часто создавать партиции и удалять устаревшие
+	retentionInterval = time.Hour
+	// partitionsAhead — на сколько суток вперед держать готовые партиции, чтобы проверки не копились в default
+	partitionsAhead = 3 * 24 * time.Hour
+)
+
+// RetentionJob обслуживает суточные партиции location_checks: создает будущие и удаляет старше retention
+type RetentionJob struct {
+	repo      domain.CheckRetentionRepository
+	retention time.Duration // 0 — хранить вечно
+	rollup    bool
+}
+
+func NewRetentionJob(repo domain.CheckRetentionRepository, retention time.Duration, rollup bool) *RetentionJob {
+	return &RetentionJob{repo: repo, retention: retention, rollup: rollup}
+}
+
+// Run выполняет задачу сразу и затем раз в retentionInterval до отмены ctx
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RetentionJob) runOnce(ctx context.Context) {
+	now := time.Now()
+
+	created, err := j.repo.EnsurePartitions(ctx, now.Add(partitionsAhead))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create location_checks partitions", logging.Err(err))
+	} else if len(created) > 0 {
+		slog.InfoContext(ctx, "location_checks partitions created", "partitions", created)
+	}
+
+	if j.retention <= 0 {
+		return
+	}
+	dropped, purged, err := j.repo.DropBefore(ctx, now.Add(-j.retention), j.rollup)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to apply location_checks retention", logging.Err(err))
+		return
+	}
+	metrics.CheckPartitionsDropped.Add(float64(len(dropped)))
+	if len(dropped) > 0 || purged > 0 {
+		slog.InfoContext(ctx, "location_checks retention applied",
+			"dropped_partitions", dropped, "purged_rows", purged, "rollup", j.rollup)
+	}
+}
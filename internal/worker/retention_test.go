@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeRetention запоминает вызовы; ensureErr — партиции создать не удалось
+type fakeRetention struct {
+	ensureErr error
+
+	until   time.Time
+	dropped bool
+	before  time.Time
+	rollup  bool
+}
+
+func (f *fakeRetention) EnsurePartitions(_ context.Context, until time.Time) ([]string, error) {
+	f.until = until
+	return nil, f.ensureErr
+}
+
+func (f *fakeRetention) DropBefore(_ context.Context, before time.Time, rollup bool) ([]string, int64, error) {
+	f.dropped, f.before, f.rollup = true, before, rollup
+	return []string{"location_checks_p20260101"}, 3, nil
+}
+
+func TestRetentionJobRunOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		rollup    bool
+		ensureErr error
+		wantDrop  bool
+	}{
+		{"keep forever", 0, true, nil, false},
+		{"drop without rollup", 7 * 24 * time.Hour, false, nil, true},
+		{"rollup before drop", 7 * 24 * time.Hour, true, nil, true},
+		// Не создались партиции вперед — старые все равно удаляем
+		{"drop after ensure failed", 7 * 24 * time.Hour, true, errors.New("db down"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRetention{ensureErr: tt.ensureErr}
+			start := time.Now()
+			NewRetentionJob(repo, tt.retention, tt.rollup).runOnce(context.Background())
+			end := time.Now()
+
+			if repo.until.Before(start.Add(partitionsAhead)) || repo.until.After(end.Add(partitionsAhead)) {
+				t.Errorf("partitions until %s, want now + %s", repo.until, partitionsAhead)
+			}
+			if repo.dropped != tt.wantDrop {
+				t.Fatalf("DropBefore called = %v, want %v", repo.dropped, tt.wantDrop)
+			}
+			if !tt.wantDrop {
+				return
+			}
+			if repo.before.Before(start.Add(-tt.retention)) || repo.before.After(end.Add(-tt.retention)) {
+				t.Errorf("drop before %s, want now - %s", repo.before, tt.retention)
+			}
+			if repo.rollup != tt.rollup {
+				t.Errorf("rollup = %v, want %v", repo.rollup, tt.rollup)
+			}
+		})
+	}
+}
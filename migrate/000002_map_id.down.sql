@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_incidents_map_status;
+
+ALTER TABLE location_checks DROP COLUMN IF EXISTS map_id;
+ALTER TABLE incidents DROP COLUMN IF EXISTS map_id;
@@ -0,0 +1,10 @@
+-- Независимые карты/шарды: инциденты и проверки живут в своем пространстве координат
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS map_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+ALTER TABLE location_checks
+    ADD COLUMN IF NOT EXISTS map_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- Для выборки активных инцидентов карты при заполнении кэша
+CREATE INDEX IF NOT EXISTS idx_incidents_map_status
+    ON incidents (map_id, status);
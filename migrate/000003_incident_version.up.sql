@@ -0,0 +1,3 @@
+-- Версия для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_incidents_not_deleted;
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Soft delete: DELETE /incidents/:id помечает запись, а не меняет статус
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_not_deleted
+    ON incidents (id) WHERE deleted_at IS NULL;
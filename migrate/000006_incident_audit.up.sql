@@ -0,0 +1,13 @@
+-- Журнал изменений инцидентов. Без внешнего ключа: история переживает окончательное удаление
+CREATE TABLE IF NOT EXISTS incident_audit (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id INT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL,
+    actor_name VARCHAR(255) NOT NULL,
+    actor_kind VARCHAR(20) NOT NULL,
+    incident JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_audit_incident ON incident_audit (incident_id, id DESC);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_incident_audit_tenant_incident;
+DROP INDEX IF EXISTS idx_location_checks_tenant_created;
+DROP INDEX IF EXISTS idx_incidents_tenant_active;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE incident_audit DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE location_checks DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE incidents DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,27 @@
+-- Тенанты: у каждой студии свои инциденты, проверки, ключи и вебхуки
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    rate_limit INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Всё, что было до тенантов, принадлежит тенанту хостинга
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE location_checks
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE incident_audit
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_tenant_active
+    ON incidents (tenant_id, map_id, status) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_location_checks_tenant_created
+    ON location_checks (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_incident_audit_tenant_incident
+    ON incident_audit (tenant_id, incident_id, id DESC);
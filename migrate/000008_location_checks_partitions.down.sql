@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS location_checks_hourly;
+
+CREATE TABLE location_checks_plain (LIKE location_checks INCLUDING DEFAULTS);
+INSERT INTO location_checks_plain SELECT * FROM location_checks;
+
+-- Последовательность переживает удаление партиционированной таблицы и снова принадлежит обычной
+ALTER SEQUENCE location_checks_id_seq OWNED BY NONE;
+DROP TABLE location_checks;
+ALTER TABLE location_checks_plain RENAME TO location_checks;
+ALTER SEQUENCE location_checks_id_seq OWNED BY location_checks.id;
+
+ALTER TABLE location_checks ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE location_checks ADD PRIMARY KEY (id);
+ALTER TABLE location_checks ADD FOREIGN KEY (tenant_id) REFERENCES tenants (id);
+
+CREATE INDEX idx_location_checks_stats ON location_checks (created_at, user_id);
+CREATE INDEX idx_location_checks_tenant_created ON location_checks (tenant_id, created_at);
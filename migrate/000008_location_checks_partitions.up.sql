@@ -0,0 +1,40 @@
+-- Проверки местоположения режутся по суткам (UTC): устаревшие сутки удаляются DROP TABLE, а не DELETE.
+-- Суточные партиции вперед создает фоновая задача retention
+UPDATE location_checks SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE location_checks ALTER COLUMN created_at SET NOT NULL;
+
+ALTER TABLE location_checks RENAME TO location_checks_legacy;
+ALTER INDEX idx_location_checks_stats RENAME TO location_checks_legacy_stats_idx;
+ALTER INDEX idx_location_checks_tenant_created RENAME TO location_checks_legacy_tenant_created_idx;
+
+CREATE TABLE location_checks (LIKE location_checks_legacy INCLUDING DEFAULTS) PARTITION BY RANGE (created_at);
+-- Последовательность id общая: иначе она уйдет вместе со старой партицией
+ALTER SEQUENCE location_checks_id_seq OWNED BY location_checks.id;
+
+CREATE INDEX idx_location_checks_stats ON location_checks (created_at, user_id);
+CREATE INDEX idx_location_checks_tenant_created ON location_checks (tenant_id, created_at);
+
+-- Все, что накоплено до разбиения, — одна партиция до конца текущих суток. Retention удалит ее, когда она устареет целиком
+DO $$
+BEGIN
+    EXECUTE format(
+        'ALTER TABLE location_checks ATTACH PARTITION location_checks_legacy FOR VALUES FROM (MINVALUE) TO (%L)',
+        (date_trunc('day', NOW() AT TIME ZONE 'UTC') + INTERVAL '1 day') AT TIME ZONE 'UTC'
+    );
+END
+$$;
+
+-- Сюда попадают строки, для которых еще нет суточной партиции. Задача переносит их при создании партиции
+CREATE TABLE location_checks_default PARTITION OF location_checks DEFAULT;
+
+ALTER TABLE location_checks ADD FOREIGN KEY (tenant_id) REFERENCES tenants (id);
+
+-- Почасовые агрегаты удаленных проверок (LOCATION_CHECKS_ROLLUP)
+CREATE TABLE IF NOT EXISTS location_checks_hourly (
+    tenant_id VARCHAR(64) NOT NULL,
+    map_id VARCHAR(64) NOT NULL,
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    checks BIGINT NOT NULL,
+    unique_users BIGINT NOT NULL,
+    PRIMARY KEY (tenant_id, map_id, hour)
+);
@@ -0,0 +1,9 @@
+// Package migrate — SQL-миграции схемы, вшитые в бинарник. Применяет их repository.Migrator
+package migrate
+
+import "embed"
+
+// Files — пары NNNNNN_name.up.sql / NNNNNN_name.down.sql
+//
+//go:embed *.sql
+var Files embed.FS